@@ -17,7 +17,11 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -42,26 +46,258 @@ type HDFSSpec struct {
 
 	Datanode Datanode `json:"datanode"`
 
-	ZkQuorum  string `json:"zkQuorum"`
+	// DatanodePools are additional groups of datanodes, each with its own StatefulSet, registered
+	// with the same nameservice as Datanode.
+	DatanodePools []DatanodePool `json:"datanodePools,omitempty"`
+
+	// ZkQuorum is the connection string of an externally managed ZooKeeper, required unless
+	// Zookeeper is set.
+	ZkQuorum  string `json:"zkQuorum,omitempty"`
+
+	// Zookeeper deploys a ZooKeeper ensemble along with the cluster when ZkQuorum is empty.
+	Zookeeper *Zookeeper `json:"zookeeper,omitempty"`
+
+	// Network selects how the daemons reach each other, the host network by default.
+	Network *Network `json:"network,omitempty"`
+
+	// ExternalAccess exposes the namenodes and the datanodes to clients outside of Kubernetes.
+	ExternalAccess *ExternalAccess `json:"externalAccess,omitempty"`
+
+	// ClientConfig copies the client configuration of the cluster to other namespaces.
+	ClientConfig *ClientConfig `json:"clientConfig,omitempty"`
+
+	// UI routes HTTP traffic to the web UIs of the active namenode and of the ResourceManager.
+	UI *UI `json:"ui,omitempty"`
+
+	// NetworkPolicy restricts the traffic reaching the pods of the cluster to the flows between its roles.
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty"`
 
 	CoreSite []ClusterConfig  `json:"coreSite,omitempty"`
 
 	HdfsSite []ClusterConfig  `json:"hdfsSite,omitempty"`
 
+	// ConfigFrom overlays whole site files (core-site.xml, hdfs-site.xml, ...) kept in
+	// ConfigMaps or Secrets of the HDFS namespace on top of the rendered configuration.
+	ConfigFrom []ConfigFileSource `json:"configFrom,omitempty"`
+
 	Yarn  Yarn `json:"yarn,omitempty"`
 
+	// RackAwareness places HDFS blocks according to the topology of the nodes running the datanodes.
+	RackAwareness *RackAwareness `json:"rackAwareness,omitempty"`
+
+	// Paths are directories the operator creates and keeps configured through WebHDFS once the
+	// namenodes are up.
+	Paths []HDFSPath `json:"paths,omitempty"`
+
+	// DirectoryTenants are the namespaces allowed to manage directories of the cluster with
+	// HDFSDirectories, each under its own path prefix. HDFSDirectories of the namespace of the
+	// cluster may use any path unless it is listed, the ones of other namespaces are rejected.
+	DirectoryTenants []DirectoryTenant `json:"directoryTenants,omitempty"`
+
+	// Backup periodically saves the namenode metadata.
+	Backup *Backup `json:"backup,omitempty"`
+
+	// Restore bootstraps the namenodes of a new cluster from a backup instead of formatting them.
+	// It is ignored once the namenodes have metadata.
+	Restore *Restore `json:"restore,omitempty"`
+}
+
+// BackupStorage is where the namenode metadata backups are kept, either an S3 compatible
+// bucket or a persistent volume claim.
+type BackupStorage struct {
+	S3 *S3Storage `json:"s3,omitempty"`
+
+	PVC *PVCStorage `json:"pvc,omitempty"`
+}
+
+type S3Storage struct {
+	// Endpoint of an S3 compatible service, AWS S3 if empty
+	Endpoint string `json:"endpoint,omitempty"`
+
+	Region string `json:"region,omitempty"`
+
+	Bucket string `json:"bucket"`
+
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecret holds the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys.
+	CredentialsSecret *corev1.LocalObjectReference `json:"credentialsSecret,omitempty"`
+
+	// Image runs the AWS CLI, amazon/aws-cli by default.
+	Image string `json:"image,omitempty"`
+}
+
+type PVCStorage struct {
+	ClaimName string `json:"claimName"`
+
+	SubPath string `json:"subPath,omitempty"`
+}
+
+// Backup runs hdfs dfsadmin -fetchImage on a schedule and stores the image with a manifest
+// under <storage>/<cluster name>-<UTC time>.
+type Backup struct {
+	BackupStorage `json:",inline"`
+
+	// Schedule is a cron expression, @daily by default.
+	Schedule string `json:"schedule,omitempty"`
+
+	// KeepLast is the number of backups kept, 7 by default.
+	// +kubebuilder:validation:Minimum=1
+	KeepLast *int32 `json:"keepLast,omitempty"`
+
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// Restore points to the backup the namenodes are bootstrapped from
+type Restore struct {
+	BackupStorage `json:",inline"`
+
+	// BackupName is the name of the backup, e.g. mycluster-20210730-100000.
+	BackupName string `json:"backupName"`
+}
+
+// DirectoryTenant allows the HDFSDirectories of a namespace under a path prefix
+type DirectoryTenant struct {
+	Namespace string `json:"namespace"`
+
+	// PathPrefix is the directory the paths of the namespace must be in, e.g. /tenants/team-a
+	// +kubebuilder:validation:Pattern=`^/.*`
+	PathPrefix string `json:"pathPrefix"`
+}
+
+// HDFSPath is the desired state of an HDFS directory. Unset fields are not managed.
+type HDFSPath struct {
+	// +kubebuilder:validation:Pattern=`^/.*`
+	Path string `json:"path"`
+
+	Owner string `json:"owner,omitempty"`
+
+	Group string `json:"group,omitempty"`
+
+	// Mode is the octal permission of the directory, e.g. 755
+	// +kubebuilder:validation:Pattern=`^[0-7]{3,4}$`
+	Mode string `json:"mode,omitempty"`
+
+	// StoragePolicy is a block storage policy such as HOT, WARM, COLD, ALL_SSD or ONE_SSD.
+	StoragePolicy string `json:"storagePolicy,omitempty"`
+
+	// ECPolicy is an erasure coding policy such as RS-6-3-1024k, enabled if needed.
+	ECPolicy string `json:"ecPolicy,omitempty"`
+
+	// NameQuota limits the number of files and directories under the path.
+	// +kubebuilder:validation:Minimum=1
+	NameQuota *int64 `json:"nameQuota,omitempty"`
+
+	// SpaceQuota limits the raw space used under the path, replicas included.
+	SpaceQuota *resource.Quantity `json:"spaceQuota,omitempty"`
+}
+
+// RackAwareness maps a node label to the HDFS racks of the datanodes: a datanode scheduled on
+// a node gets the rack /<node label value>/<node name>.
+type RackAwareness struct {
+	// NodeLabel defaults to topology.kubernetes.io/zone
+	NodeLabel string `json:"nodeLabel,omitempty"`
 }
 
 type Yarn struct {
-	Name string `json:"name"`
+	// Name prefixes the YARN resources, the name of the YarnCluster for a YarnCluster.
+	Name string `json:"name,omitempty"`
 
+	// RMReplicas above 1 run the ResourceManagers in HA, electing the active one in ZooKeeper.
 	RMReplicas int32 `json:"rmReplicas"`
 
 	NMReplicas int32 `json:"nmReplicas"`
 
+	RMResources corev1.ResourceRequirements `json:"rmResources,omitempty"`
+
+	// RMHeapPercent is the share of the ResourceManager memory limit given to its heap, 75 by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	RMHeapPercent int32 `json:"rmHeapPercent,omitempty"`
+
+	NMResources corev1.ResourceRequirements `json:"nmResources,omitempty"`
+
+	// NMHeapPercent is the share of the NodeManager memory limit given to its own heap, 15 by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	NMHeapPercent int32 `json:"nmHeapPercent,omitempty"`
+
+	// RMProbes and NMProbes tune the health checks of the ResourceManagers and NodeManagers.
+	RMProbes *Probes `json:"rmProbes,omitempty"`
+
+	NMProbes *Probes `json:"nmProbes,omitempty"`
+
+	// NMContainerPercent is the share of the NodeManager memory limit offered to YARN
+	// containers as yarn.nodemanager.resource.memory-mb, 75 by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	NMContainerPercent int32 `json:"nmContainerPercent,omitempty"`
+
 	MapredSite []ClusterConfig  `json:"mapredSite,omitempty"`
 
 	YarnSite []ClusterConfig  `json:"yarnSite,omitempty"`
+
+	// HistoryServer runs the MapReduce JobHistory server, serving the finished jobs and their logs.
+	HistoryServer *YarnServer `json:"historyServer,omitempty"`
+
+	// TimelineServer runs the YARN application history and timeline server (v1.5).
+	TimelineServer *YarnServer `json:"timelineServer,omitempty"`
+
+	// LogAggregation uploads the logs of finished containers to HDFS, enabled by default.
+	LogAggregation *bool `json:"logAggregation,omitempty"`
+
+	// NMStorage backs the local and log directories of the NodeManagers with persistent volumes
+	// instead of emptyDir ones.
+	NMStorage *NMStorage `json:"nmStorage,omitempty"`
+
+	// Autoscaling scales the NodeManagers on the resources the applications wait for, starting
+	// from NMReplicas. NodeManagers are decommissioned gracefully before being removed.
+	Autoscaling *NMAutoscaling `json:"autoscaling,omitempty"`
+}
+
+// NMAutoscaling bounds the NodeManagers and sets when to add or remove one
+type NMAutoscaling struct {
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas int32 `json:"minReplicas"`
+
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// ScaleUpPendingMB adds a NodeManager while at least this much memory is pending, 1024 by default.
+	ScaleUpPendingMB int64 `json:"scaleUpPendingMB,omitempty"`
+
+	// ScaleUpPendingVCores adds a NodeManager while at least this many vcores are pending, 1 by default.
+	ScaleUpPendingVCores int64 `json:"scaleUpPendingVCores,omitempty"`
+
+	// ScaleDownUtilizationPercent removes a NodeManager when nothing is pending and the allocated
+	// memory and vcores would use less than this share of the remaining NodeManagers, 50 by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	ScaleDownUtilizationPercent int32 `json:"scaleDownUtilizationPercent,omitempty"`
+
+	// DecommissionTimeoutSeconds is how long the containers of a decommissioned NodeManager may
+	// run before it is removed anyway, 600 by default.
+	DecommissionTimeoutSeconds int32 `json:"decommissionTimeoutSeconds,omitempty"`
+}
+
+// NMStorage sizes the claims of the NodeManager directories
+type NMStorage struct {
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// LocalCapacity holds the localized files and the intermediate data of the containers.
+	LocalCapacity string `json:"localCapacity"`
+
+	LogCapacity string `json:"logCapacity"`
+}
+
+// YarnServer is a single-replica YARN server keeping its state on a persistent volume
+type YarnServer struct {
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// Capacity of the state volume, 1Gi by default.
+	Capacity string `json:"capacity,omitempty"`
+
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 type NamenodeSet struct {
@@ -75,6 +311,22 @@ type NamenodeSet struct {
 
 	Replicas int32 `json:"replicas"` // default 2
 
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// HeapPercent is the share of the namenode memory limit given to its heap, 75 by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	HeapPercent int32 `json:"heapPercent,omitempty"`
+
+	// AllowReformat lets the first namenode format an empty metadata volume, or start from the
+	// metadata of another cluster, once the cluster identity is recorded in the status.
+	// Formatting loses the namespace while the datanodes still hold its blocks.
+	AllowReformat bool `json:"allowReformat,omitempty"`
+
+	// Probes tune the health checks of the namenodes. The startup probe allows 10 minutes
+	// by default for the image to be loaded and the edits to be replayed.
+	Probes *Probes `json:"probes,omitempty"`
+
 	//VolumeClaimTemplates []corev1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
 }
 
@@ -88,11 +340,160 @@ type Journalnode struct {
 	Capacity      string  `json:"capacity"`
 
 	Replicas int32 `json:"replicas"`
+
+	// Resources, like the other pod settings of the journalnodes, reach the running ones as they restart.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// HeapPercent is the journalnode counterpart of Namenode.HeapPercent.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	HeapPercent int32 `json:"heapPercent,omitempty"`
+
+	Probes *Probes `json:"probes,omitempty"`
 	//PodTemplate corev1.PodTemplateSpec `json:"podTemplate,omitempty"`
 
 	//VolumeClaimTemplates []corev1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
 }
 
+const (
+	NetworkModeHost = "host"
+	NetworkModePod  = "pod"
+)
+
+// Network is the networking of the cluster pods
+type Network struct {
+	// Mode host runs the daemons on the network of their node. Mode pod gives each daemon the
+	// stable name of its pod in a headless Service, and runs all the pods of the cluster as
+	// allowed by the restricted Pod Security Standard: the images must run as a non-root user
+	// and host path volumes are refused.
+	// +kubebuilder:validation:Enum=host;pod
+	Mode string `json:"mode,omitempty"`
+
+	// RunAsUser is the user the pods run as in pod mode, 1000 by default. Volumes are owned by
+	// its group.
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+}
+
+// ExternalAccess gives each namenode and datanode pod its own Service. The datanodes register
+// with their external address, which clients use with dfs.client.use.datanode.hostname.
+type ExternalAccess struct {
+	// Type of the Services. With NodePort the address is the node of the pod, and each datanode
+	// listens on the node port allocated to it.
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	Type corev1.ServiceType `json:"type"`
+
+	// Annotations of the Services, e.g. to request an internal load balancer.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Addresses overrides the advertised host of pods by pod name, e.g. a DNS name of the load
+	// balancer or a public IP of the node.
+	Addresses map[string]string `json:"addresses,omitempty"`
+}
+
+// ClientConfig selects the namespaces receiving a copy of the client configuration ConfigMap
+type ClientConfig struct {
+	// NamespaceSelector matches the labels of the namespaces the ConfigMap is copied to, under
+	// the same name. A ConfigMap of that name not copied from this cluster is not overwritten.
+	// Copies are removed from namespaces no longer matching, but are left behind when the cluster
+	// is deleted.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// NetworkPolicy allows peers outside of the cluster to reach its pods. Policies do not select
+// pods of the host network, only the pod network mode isolates the cluster.
+type NetworkPolicy struct {
+	// Clients reach the namenodes and the datanodes, and the ResourceManager and NodeManagers
+	// with YARN. Ingress controllers and, with external access, load balancer addresses are
+	// clients too.
+	Clients []networkingv1.NetworkPolicyPeer `json:"clients,omitempty"`
+
+	// Metrics scrape the HTTP ports of every role, e.g. Prometheus.
+	Metrics []networkingv1.NetworkPolicyPeer `json:"metrics,omitempty"`
+}
+
+const (
+	UIRouteIngress   = "Ingress"
+	UIRouteHTTPRoute = "HTTPRoute"
+)
+
+// UI exposes the web UIs, each on its own host. UIs without a host are not exposed.
+type UI struct {
+	// Type of the resources routing to the UIs, Ingress by default.
+	// +kubebuilder:validation:Enum=Ingress;HTTPRoute
+	Type string `json:"type,omitempty"`
+
+	NamenodeHost string `json:"namenodeHost,omitempty"`
+
+	ResourceManagerHost string `json:"resourceManagerHost,omitempty"`
+
+	// JobHistoryHost routes to the JobHistory server, if run.
+	JobHistoryHost string `json:"jobHistoryHost,omitempty"`
+
+	// IngressClassName of the Ingresses.
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// Annotations of the Ingresses, e.g. nginx.ingress.kubernetes.io/auth-url and auth-signin
+	// to authenticate users with an oauth2-proxy.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// TLSSecretName holds the certificate of the Ingresses hosts. HTTPRoutes rely on the TLS
+	// listeners of their Gateway.
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Gateway the HTTPRoutes attach to.
+	Gateway *GatewayReference `json:"gateway,omitempty"`
+}
+
+// GatewayReference is the parent Gateway of the HTTPRoutes
+type GatewayReference struct {
+	Name string `json:"name"`
+
+	// Namespace of the Gateway, the one of the cluster by default.
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the listener of the Gateway, all listeners by default.
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// Probes overrides the thresholds of the startup, readiness and liveness probes of a role
+type Probes struct {
+	Startup *ProbeThresholds `json:"startup,omitempty"`
+
+	Readiness *ProbeThresholds `json:"readiness,omitempty"`
+
+	Liveness *ProbeThresholds `json:"liveness,omitempty"`
+}
+
+// ProbeThresholds are the timing fields of a probe, unset ones keep the role defaults.
+type ProbeThresholds struct {
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// Zookeeper is the ensemble used by the namenode failover controllers and the ResourceManager
+type Zookeeper struct {
+	// Name is zk by default.
+	Name string `json:"name,omitempty"`
+
+	// Image is zookeeper:3.6.3 by default.
+	Image string `json:"image,omitempty"`
+
+	// +kubebuilder:validation:Enum=3;5
+	Replicas int32 `json:"replicas"`
+
+	StorageClass  string `json:"storageClass"`
+
+	Capacity      string  `json:"capacity"`
+
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
 type Datanode struct {
 	Name string `json:"name"`
 
@@ -104,20 +505,210 @@ type Datanode struct {
 
 	Capacity      string  `json:"capacity"`
 
-	Datadirs []string `json:"datadirs"`
+	// Datadirs are sub directories of a single claim, prefer Volumes to spread data over several disks.
+	Datadirs []string `json:"datadirs,omitempty"`
+
+	// Volumes are data directories each backed by its own claim or host directory.
+	Volumes []DatanodeVolume `json:"volumes,omitempty"`
+
+	// Resources are applied to the running datanodes as they restart, one at a time at the pace of the
+	// administrator, while the namenodes and the YARN daemons are rolled right away.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// HeapPercent is the datanode counterpart of Namenode.HeapPercent.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	HeapPercent int32 `json:"heapPercent,omitempty"`
+
+	// MaxUnavailable is the number or percentage of datanodes a voluntary disruption such as
+	// a node drain may take down at once, 1 by default.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	Probes *Probes `json:"probes,omitempty"`
 	//VolumeClaim []VolumeClaim   `json:"volumeClaim"`
 
 }
 
+// DatanodePool is a group of identical datanodes, e.g. an SSD hot pool or a large HDD archive pool.
+// Its name must differ from the other pools and from Datanode.
+type DatanodePool struct {
+	Datanode `json:",inline"`
+
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Decommission moves the blocks of the pool to the other datanodes, after which the pool can be
+	// scaled down or removed without losing data. Datanodes removed by scaling a pool down, or by
+	// removing it from the spec, are decommissioned before their pods are deleted as well.
+	Decommission bool `json:"decommission,omitempty"`
+}
+
+// HDFS storage types of the datanode volumes
+const (
+	StorageTypeDisk    = "DISK"
+	StorageTypeSSD     = "SSD"
+	StorageTypeArchive = "ARCHIVE"
+	StorageTypeRamDisk = "RAM_DISK"
+)
+
+// DatanodeVolume is a data directory of the datanodes, mounted at /hadoop/dfs/data/<name>.
+type DatanodeVolume struct {
+	Name string `json:"name"`
+
+	// StorageType tags the directory in dfs.datanode.data.dir for heterogeneous storage, DISK by default.
+	// +kubebuilder:validation:Enum=DISK;SSD;ARCHIVE;RAM_DISK
+	StorageType string `json:"storageType,omitempty"`
+
+	// StorageClass of the claim, the datanode storage class by default.
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// Capacity of the claim, the datanode capacity by default.
+	Capacity string `json:"capacity,omitempty"`
+
+	// HostPath mounts a directory of the node, such as a JBOD disk, instead of a claim.
+	HostPath string `json:"hostPath,omitempty"`
+}
+
 type ClusterConfig struct {
 	Property string `json:"property"`
-	Value    string `json:"value"`
+	Value    string `json:"value,omitempty"`
+	// ValueFrom reads the value from a key of a ConfigMap or Secret instead of Value.
+	ValueFrom *ConfigValueSource `json:"valueFrom,omitempty"`
+}
+
+// ConfigValueSource selects the ConfigMap or Secret key holding a property value.
+// Values read from a Secret are never written to the plain config map.
+type ConfigValueSource struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// ConfigFileSource references a ConfigMap or Secret whose keys are site file names
+// (e.g. core-site.xml) holding Hadoop configuration XML.
+type ConfigFileSource struct {
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// Volume expansion phases
+const (
+	// VolumeResizing means the claim was given a larger size that is not provisioned yet.
+	VolumeResizing = "Resizing"
+	// VolumeFileSystemResizePending means the volume is expanded but the file system still has to be,
+	// which for some drivers happens only when the pod restarts.
+	VolumeFileSystemResizePending = "FileSystemResizePending"
+	// VolumeExpansionUnsupported means the storage class does not allow volume expansion.
+	VolumeExpansionUnsupported = "Unsupported"
+)
+
+// VolumeExpansion reports a persistent volume claim being resized after a capacity increase.
+type VolumeExpansion struct {
+	ClaimName string `json:"claimName"`
+
+	// Requested is the capacity the claim is being resized to.
+	Requested string `json:"requested"`
+
+	// Capacity is the capacity currently provisioned.
+	Capacity string `json:"capacity,omitempty"`
+
+	Phase string `json:"phase"`
+
+	Message string `json:"message,omitempty"`
 }
 
 // HDFSStatus defines the observed state of HDFS
 type HDFSStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ClientConfig is the ConfigMap holding the core-site.xml and hdfs-site.xml clients of the
+	// cluster mount, without any server side setting.
+	ClientConfig string `json:"clientConfig,omitempty"`
+
+	// ExternalClientConfig is the ConfigMap holding the client configuration of the external
+	// addresses, once all the namenodes have one.
+	ExternalClientConfig string `json:"externalClientConfig,omitempty"`
+
+	// ClusterID and BlockPoolID identify the namespace the namenodes were formatted with.
+	ClusterID string `json:"clusterID,omitempty"`
+
+	BlockPoolID string `json:"blockPoolID,omitempty"`
+
+	// VolumeExpansions lists the claims whose expansion is not complete.
+	VolumeExpansions []VolumeExpansion `json:"volumeExpansions,omitempty"`
+
+	DatanodePools []DatanodePoolStatus `json:"datanodePools,omitempty"`
+
+	// Zkfc is the health of the failover controller of each namenode.
+	Zkfc []ZkfcStatus `json:"zkfc,omitempty"`
+
+	Paths []PathStatus `json:"paths,omitempty"`
+
+	Backup *BackupStatus `json:"backup,omitempty"`
+}
+
+// BackupStatus reports the runs of the backup job
+type BackupStatus struct {
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+}
+
+// Path phases
+const (
+	// PathPending means the namenodes are not ready to apply the path yet.
+	PathPending = "Pending"
+	// PathApplied means the directory matches its spec.
+	PathApplied = "Applied"
+	// PathFailed means the last attempt to apply the path failed.
+	PathFailed = "Failed"
+)
+
+// PathStatus reports whether an entry of spec.paths is applied
+type PathStatus struct {
+	Path string `json:"path"`
+
+	Phase string `json:"phase"`
+
+	Message string `json:"message,omitempty"`
+
+	// LastAppliedTime is the last time the directory was changed to match its spec,
+	// either on creation or after a drift.
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+}
+
+// ZkfcStatus is the observed state of the ZKFC container of a namenode pod
+type ZkfcStatus struct {
+	Pod string `json:"pod"`
+
+	Healthy bool `json:"healthy"`
+
+	RestartCount int32 `json:"restartCount"`
+
+	// Message tells why the controller last terminated, if it did.
+	Message string `json:"message,omitempty"`
+}
+
+// DatanodePoolStatus is the observed state of a datanode pool
+type DatanodePoolStatus struct {
+	Name string `json:"name"`
+
+	Replicas int32 `json:"replicas"`
+
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Decommissioning is true while datanodes of the pool, decommissioned or scaled down, are
+	// excluded from the cluster and the namenode still copies their blocks.
+	Decommissioning bool `json:"decommissioning,omitempty"`
+
+	// Decommissioned is true once the namenode reports every datanode of a decommissioned pool
+	// as decommissioned, the pool can then be scaled down or removed.
+	Decommissioned bool `json:"decommissioned,omitempty"`
 }
 
 //+kubebuilder:object:root=true
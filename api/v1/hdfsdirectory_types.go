@@ -0,0 +1,116 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Deletion policies of an HDFSDirectory
+const (
+	// DirectoryRetain keeps the directory and its data when the HDFSDirectory is deleted.
+	DirectoryRetain = "Retain"
+	// DirectoryDelete removes the directory and its data when the HDFSDirectory is deleted.
+	DirectoryDelete = "Delete"
+)
+
+// ClusterReference points to an HDFS cluster
+type ClusterReference struct {
+	Name string `json:"name"`
+
+	// Namespace defaults to the namespace of the referencing resource.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// HDFSDirectorySpec defines the desired state of HDFSDirectory
+type HDFSDirectorySpec struct {
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	HDFSPath `json:",inline"`
+
+	// ACLs are the named ACL entries of the directory, e.g. user:alice:r-x or default:group:analysts:rwx.
+	// Entries not listed are removed. ACLs must be enabled with dfs.namenode.acls.enabled.
+	ACLs []string `json:"acls,omitempty"`
+
+	// DeletionPolicy tells whether the directory is removed with the HDFSDirectory, Retain by default.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+}
+
+// DirectoryUsage is the content summary of a directory
+type DirectoryUsage struct {
+	Files int64 `json:"files"`
+
+	Directories int64 `json:"directories"`
+
+	// Bytes is the length of the files, replicas excluded.
+	Bytes int64 `json:"bytes"`
+
+	// SpaceConsumed is the raw space used, replicas included, as counted by the space quota.
+	SpaceConsumed int64 `json:"spaceConsumed"`
+}
+
+// HDFSDirectoryStatus defines the observed state of HDFSDirectory
+type HDFSDirectoryStatus struct {
+	Phase string `json:"phase,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	Usage *DirectoryUsage `json:"usage,omitempty"`
+
+	// LastAppliedTime is the last time the directory was changed to match its spec.
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterRef.name`
+//+kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.spec.path`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// HDFSDirectory is the Schema for the hdfsdirectories API
+type HDFSDirectory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HDFSDirectorySpec   `json:"spec,omitempty"`
+	Status HDFSDirectoryStatus `json:"status,omitempty"`
+}
+
+// ClusterNamespace returns the namespace of the referenced HDFS cluster
+func (in HDFSDirectory) ClusterNamespace() string {
+	if in.Spec.ClusterRef.Namespace != "" {
+		return in.Spec.ClusterRef.Namespace
+	}
+	return in.Namespace
+}
+
+//+kubebuilder:object:root=true
+
+// HDFSDirectoryList contains a list of HDFSDirectory
+type HDFSDirectoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HDFSDirectory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HDFSDirectory{}, &HDFSDirectoryList{})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotRetention limits the snapshots kept by a schedule, the latest snapshot is always kept.
+type SnapshotRetention struct {
+	// KeepLast is the number of most recent snapshots to keep.
+	// +kubebuilder:validation:Minimum=1
+	KeepLast *int32 `json:"keepLast,omitempty"`
+
+	// MaxAge deletes the snapshots older than the duration, e.g. 168h.
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// HDFSSnapshotScheduleSpec defines the desired state of HDFSSnapshotSchedule
+type HDFSSnapshotScheduleSpec struct {
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Path is the directory to snapshot, made snapshottable if needed.
+	// +kubebuilder:validation:Pattern=`^/.*`
+	Path string `json:"path"`
+
+	// Schedule is a cron expression in UTC, e.g. "0 */6 * * *" or @daily.
+	Schedule string `json:"schedule"`
+
+	Retention SnapshotRetention `json:"retention,omitempty"`
+
+	// Suspend stops taking new snapshots, existing ones are still pruned.
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// HDFSSnapshotScheduleStatus defines the observed state of HDFSSnapshotSchedule
+type HDFSSnapshotScheduleStatus struct {
+	LastSnapshotName string `json:"lastSnapshotName,omitempty"`
+
+	LastSnapshotTime *metav1.Time `json:"lastSnapshotTime,omitempty"`
+
+	NextSnapshotTime *metav1.Time `json:"nextSnapshotTime,omitempty"`
+
+	// Snapshots is the number of snapshots of the schedule kept.
+	Snapshots int32 `json:"snapshots,omitempty"`
+
+	LastFailure string `json:"lastFailure,omitempty"`
+
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+
+	// ConsecutiveFailures is reset by the next successful snapshot.
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.spec.path`
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Last Snapshot",type=string,JSONPath=`.status.lastSnapshotName`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// HDFSSnapshotSchedule is the Schema for the hdfssnapshotschedules API
+type HDFSSnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HDFSSnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status HDFSSnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+// ClusterNamespace returns the namespace of the referenced HDFS cluster
+func (in HDFSSnapshotSchedule) ClusterNamespace() string {
+	if in.Spec.ClusterRef.Namespace != "" {
+		return in.Spec.ClusterRef.Namespace
+	}
+	return in.Namespace
+}
+
+//+kubebuilder:object:root=true
+
+// HDFSSnapshotScheduleList contains a list of HDFSSnapshotSchedule
+type HDFSSnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HDFSSnapshotSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HDFSSnapshotSchedule{}, &HDFSSnapshotScheduleList{})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// YarnCluster phases
+const (
+	// YarnClusterPending means the referenced HDFS cluster does not exist yet.
+	YarnClusterPending = "Pending"
+	// YarnClusterRunning means the YARN resources are reconciled.
+	YarnClusterRunning = "Running"
+	// YarnClusterFailed means the spec cannot be applied.
+	YarnClusterFailed = "Failed"
+)
+
+// YarnClusterSpec defines the desired state of YarnCluster
+type YarnClusterSpec struct {
+	// HDFS is the name of the HDFS cluster of the namespace the YARN cluster reads and writes.
+	HDFS string `json:"hdfs"`
+
+	// Image and Version default to the ones of the HDFS cluster, set them to upgrade YARN on its own.
+	Image string `json:"image,omitempty"`
+
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+
+	Version string `json:"version,omitempty"`
+
+	Yarn `json:",inline"`
+}
+
+// YarnClusterStatus defines the observed state of YarnCluster
+type YarnClusterStatus struct {
+	Phase string `json:"phase,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	// ActiveResourceManager is the pod of the active ResourceManager.
+	ActiveResourceManager string `json:"activeResourceManager,omitempty"`
+
+	ReadyNodeManagers int32 `json:"readyNodeManagers,omitempty"`
+
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="HDFS",type=string,JSONPath=`.spec.hdfs`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.activeResourceManager`
+//+kubebuilder:printcolumn:name="NodeManagers",type=integer,JSONPath=`.status.readyNodeManagers`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// YarnCluster is the Schema for the yarnclusters API, a YARN cluster running against an HDFS
+// cluster with its own lifecycle.
+type YarnCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   YarnClusterSpec   `json:"spec,omitempty"`
+	Status YarnClusterStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// YarnClusterList contains a list of YarnCluster
+type YarnClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []YarnCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&YarnCluster{}, &YarnClusterList{})
+}
@@ -0,0 +1,98 @@
+package common
+
+import (
+	"encoding/xml"
+	"fmt"
+	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+// Nameservice is the logical name of the HA namenodes of every cluster
+const Nameservice = "hdfs-k8s"
+
+// NamenodeAddress is where clients reach a namenode, as host:port
+type NamenodeAddress struct {
+	RPC  string
+	HTTP string
+}
+
+// InternalNamenodeAddresses returns the addresses of the namenodes in their headless Service
+func InternalNamenodeAddresses(hdfs hdfsv1.HDFS) []NamenodeAddress {
+	nnPrefix := GetName(hdfs.Name, hdfs.Spec.Namenode.Name)
+	nnService := nnPrefix + "." + hdfs.Namespace + ".svc.cluster.local"
+	var addresses []NamenodeAddress
+	for i := 0; i < 2; i++ {
+		host := fmt.Sprintf("%s-%d.%s", nnPrefix, i, nnService)
+		addresses = append(addresses, NamenodeAddress{
+			RPC:  fmt.Sprintf("%s:%d", host, NamenodeRpcPort(hdfs.Spec.Version)),
+			HTTP: fmt.Sprintf("%s:%d", host, NamenodeHttpPort(hdfs.Spec.Version)),
+		})
+	}
+	return addresses
+}
+
+// BuildClientConfig renders the core-site.xml and hdfs-site.xml a client needs to reach the
+// nameservice through the given namenode addresses, without any server side setting.
+func BuildClientConfig(hdfs hdfsv1.HDFS, name string, namenodes []NamenodeAddress, useDatanodeHostname bool) (corev1.ConfigMap, error) {
+	core := Configuration{Configuration: []Property{{
+		Name:  "fs.defaultFS",
+		Value: "hdfs://" + Nameservice,
+	}}}
+
+	ids := make([]string, 0, len(namenodes))
+	for i := range namenodes {
+		ids = append(ids, fmt.Sprintf("nn%d", i))
+	}
+	site := Configuration{Configuration: []Property{{
+		Name:  "dfs.nameservices",
+		Value: Nameservice,
+	}, {
+		Name:  "dfs.ha.namenodes." + Nameservice,
+		Value: strings.Join(ids, ","),
+	}, {
+		Name:  "dfs.client.failover.proxy.provider." + Nameservice,
+		Value: "org.apache.hadoop.hdfs.server.namenode.ha.ConfiguredFailoverProxyProvider",
+	}}}
+	for i, nn := range namenodes {
+		site.Configuration = append(site.Configuration, Property{
+			Name:  "dfs.namenode.rpc-address." + Nameservice + "." + ids[i],
+			Value: nn.RPC,
+		}, Property{
+			Name:  "dfs.namenode.http-address." + Nameservice + "." + ids[i],
+			Value: nn.HTTP,
+		})
+	}
+	if useDatanodeHostname {
+		site.Configuration = append(site.Configuration, Property{
+			Name:  "dfs.client.use.datanode.hostname",
+			Value: "true",
+		})
+	}
+
+	coreSiteData, err := xml.MarshalIndent(core, " ", " ")
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	hdfsSiteData, err := xml.MarshalIndent(site, " ", " ")
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            name,
+			Labels:          NewLabels(ExtractNamespacedName(&hdfs)),
+			OwnerReferences: GetOwnerReference(hdfs),
+		},
+		Data: map[string]string{
+			CoreSiteFileName: string(coreSiteData),
+			HdfsSiteFileName: string(hdfsSiteData),
+		},
+	}, nil
+}
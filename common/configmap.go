@@ -2,10 +2,12 @@ package common
 
 import (
 	"encoding/xml"
+	"fmt"
 	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -16,30 +18,48 @@ const (
 	HdfsConfigMountPath  = "/etc/hadoop-custom-conf"
 	MapredSiteFileName   = "mapred-site.xml"
 	YarnSiteFileName     = "yarn-site.xml"
-)
+	SecretConfigName     = "secret-config"
+	XIncludeNamespace    = "http://www.w3.org/2001/XInclude"
+
+	DatanodeDataPath     = "/hadoop/dfs/data/"
+
+	TopologyConfigMountPath = "/etc/hadoop-topology"
+	TopologyScriptFileName  = "topology.sh"
 
-var (
-	NamenodeRpcPort       = 8020  // 9820
-	NamenodeHttpPort      = 50070  // 9870
-	DatanodeRpcPort       = 50075 //  9864
-	//Journalnode 8485 8480
+	ExcludeConfigMountPath = "/etc/hadoop-exclude"
+	ExcludeFileName        = "dfs.exclude"
 )
 
-func BuildHdfsConfig(hdfs hdfsv1.HDFS, name string) (corev1.ConfigMap, error) {
-	coreSiteData, err := RenderCoreSiteCfg(hdfs.Spec)
-	if err != nil {
-		return corev1.ConfigMap{}, err
+// NamenodeRpcPort returns the default namenode RPC port of the Hadoop version
+func NamenodeRpcPort(version string) int {
+	if IsHadoop3(version) {
+		return 9820
 	}
-	hdfsSiteData, err := RenderHdfsSiteCfg(hdfs)
-	if err != nil {
-		return corev1.ConfigMap{}, err
+	return 8020
+}
+
+// NamenodeHttpPort returns the default namenode HTTP port of the Hadoop version
+func NamenodeHttpPort(version string) int {
+	if IsHadoop3(version) {
+		return 9870
 	}
-	// add yarn config
-	mapredSiteData, err := RenderMapredSiteCfg(hdfs.Spec.Yarn.MapredSite)
+	return 50070
+}
+
+// DatanodeRpcPort returns the default datanode HTTP port of the Hadoop version
+func DatanodeRpcPort(version string) int {
+	if IsHadoop3(version) {
+		return 9864
+	}
+	return 50075
+}
+
+func BuildHdfsConfig(hdfs hdfsv1.HDFS, name string, ext ExternalConfig) (corev1.ConfigMap, error) {
+	coreSiteData, err := RenderCoreSiteCfg(hdfs, ext)
 	if err != nil {
 		return corev1.ConfigMap{}, err
 	}
-	yarnSiteData, err := RenderYarnSiteCfg(hdfs)
+	hdfsSiteData, err := RenderHdfsSiteCfg(hdfs, ext)
 	if err != nil {
 		return corev1.ConfigMap{}, err
 	}
@@ -57,8 +77,6 @@ func BuildHdfsConfig(hdfs hdfsv1.HDFS, name string) (corev1.ConfigMap, error) {
 		Data: map[string]string{
 			CoreSiteFileName: string(coreSiteData),
 			HdfsSiteFileName: string(hdfsSiteData),
-			MapredSiteFileName: string(mapredSiteData),
-			YarnSiteFileName: string(yarnSiteData),
 		},
 	}, nil
 }
@@ -66,7 +84,9 @@ func BuildHdfsConfig(hdfs hdfsv1.HDFS, name string) (corev1.ConfigMap, error) {
 type Configuration struct {
 	XMLName       xml.Name   `xml:"configuration"`
 	Version       string     `xml:"version,attr"`
+	XmlnsXi       string     `xml:"xmlns:xi,attr,omitempty"`
 	Configuration []Property `xml:"configuration"`
+	Includes      []Include
 }
 
 type Property struct {
@@ -75,27 +95,82 @@ type Property struct {
 	Value   string   `xml:"value"`
 }
 
-func RenderCoreSiteCfg(spec hdfsv1.HDFSSpec) ([]byte, error) {
+// Include is an XInclude of another site file, skipped by the fallback when the file is missing.
+type Include struct {
+	XMLName  xml.Name  `xml:"xi:include"`
+	Href     string    `xml:"href,attr"`
+	Fallback *struct{} `xml:"xi:fallback"`
+}
+
+// appendClusterConfigs appends the inline properties. Properties with a ValueFrom
+// are resolved into the ExternalConfig instead.
+func (c *Configuration) appendClusterConfigs(cfgs []hdfsv1.ClusterConfig) {
+	for _, cfg := range cfgs {
+		if cfg.ValueFrom != nil {
+			continue
+		}
+		c.Configuration = append(c.Configuration, Property{
+			Name:  cfg.Property,
+			Value: cfg.Value,
+		})
+	}
+}
+
+// appendExternal appends the properties resolved from ConfigMaps and includes the
+// secret part of the site file, which only lives in the secret config.
+func (c *Configuration) appendExternal(file string, ext ExternalConfig) {
+	c.Configuration = append(c.Configuration, ext.Plain[file]...)
+	if len(ext.Secret[file]) > 0 {
+		c.XmlnsXi = XIncludeNamespace
+		c.Includes = append(c.Includes, Include{Href: SecretSiteFileName(file), Fallback: &struct{}{}})
+	}
+}
+
+func RenderCoreSiteCfg(hdfs hdfsv1.HDFS, ext ExternalConfig) ([]byte, error) {
 	var c = Configuration{}
+	spec := hdfs.Spec
 
 	var zkCfg = Property{}
 	zkCfg.Name = "ha.zookeeper.quorum"
-	zkCfg.Value = spec.ZkQuorum
+	zkCfg.Value = ZkQuorum(hdfs)
 
 	c.Configuration = append(c.Configuration, Property{
 		Name:  "fs.defaultFS",
 		Value: "hdfs://hdfs-k8s",
 	}, zkCfg)
-	for _, cfg := range spec.CoreSite {
+	if spec.RackAwareness != nil {
 		c.Configuration = append(c.Configuration, Property{
-			Name:  cfg.Property,
-			Value: cfg.Value,
+			Name:  "net.topology.script.file.name",
+			Value: TopologyConfigMountPath + "/" + TopologyScriptFileName,
 		})
 	}
+	c.appendClusterConfigs(spec.CoreSite)
+	c.appendExternal(CoreSiteFileName, ext)
 	return xml.MarshalIndent(c, " ", " ")
 }
 
-func RenderHdfsSiteCfg(hdfs hdfsv1.HDFS) ([]byte, error) {
+// DatanodeDataDirs returns the dfs.datanode.data.dir of the datanodes, with the storage type
+// of each volume, e.g. [SSD]/hadoop/dfs/data/ssd0,[DISK]/hadoop/dfs/data/hdd0
+func DatanodeDataDirs(dn hdfsv1.Datanode) string {
+	dataDirs := ""
+	if len(dn.Volumes) > 0 {
+		dirs := make([]string, 0, len(dn.Volumes))
+		for _, v := range dn.Volumes {
+			storageType := v.StorageType
+			if storageType == "" {
+				storageType = hdfsv1.StorageTypeDisk
+			}
+			dirs = append(dirs, "["+storageType+"]"+DatanodeDataPath+v.Name)
+		}
+		return strings.Join(dirs, ",")
+	}
+	for _, dir := range dn.Datadirs {
+		dataDirs = dataDirs + DatanodeDataPath + dir + ","
+	}
+	return dataDirs
+}
+
+func RenderHdfsSiteCfg(hdfs hdfsv1.HDFS, ext ExternalConfig) ([]byte, error) {
 
 	var c = Configuration{}
 
@@ -108,10 +183,7 @@ func RenderHdfsSiteCfg(hdfs hdfsv1.HDFS) ([]byte, error) {
 	editsDir :=  "qjournal://"+ jnPrefix+"-0." + jnService+":8485;"+ jnPrefix+"-1."+ jnService+":8485;"+ jnPrefix+"-2."+ jnService+":8485" + "/hdfs-k8s"
 
 	//get dn  MountPaths
-	dataDirs := ""
-	for _, dir := range hdfs.Spec.Datanode.Datadirs {
-		dataDirs = dataDirs+"/hadoop/dfs/data/"+dir+","  // const DNDataVolumeMountPath = /hadoop/dfs/data
-	}
+	dataDirs := DatanodeDataDirs(hdfs.Spec.Datanode)
 
 	c.Configuration = append(c.Configuration, Property{
 		Name:  "dfs.nameservices",
@@ -121,16 +193,16 @@ func RenderHdfsSiteCfg(hdfs hdfsv1.HDFS) ([]byte, error) {
 		Value: "nn0,nn1",
 	}, Property{
 		Name:  "dfs.namenode.rpc-address.hdfs-k8s." + "nn0",
-		Value: nnPrefix+"-0." + nnService + ":"+ strconv.Itoa(NamenodeRpcPort),
+		Value: nnPrefix+"-0." + nnService + ":"+ strconv.Itoa(NamenodeRpcPort(hdfs.Spec.Version)),
 	}, Property{
 		Name:  "dfs.namenode.rpc-address.hdfs-k8s.nn1",
-		Value: nnPrefix+"-1." + nnService + ":"+strconv.Itoa(NamenodeRpcPort),
+		Value: nnPrefix+"-1." + nnService + ":"+strconv.Itoa(NamenodeRpcPort(hdfs.Spec.Version)),
 	}, Property{
 		Name:  "dfs.namenode.http-address.hdfs-k8s.nn0",
-		Value: nnPrefix+"-0." + nnService +":"+strconv.Itoa(NamenodeHttpPort),
+		Value: nnPrefix+"-0." + nnService +":"+strconv.Itoa(NamenodeHttpPort(hdfs.Spec.Version)),
 	}, Property{
 		Name:  "dfs.namenode.http-address.hdfs-k8s.nn1",
-		Value: nnPrefix+"-1." + nnService +":"+strconv.Itoa(NamenodeHttpPort),
+		Value: nnPrefix+"-1." + nnService +":"+strconv.Itoa(NamenodeHttpPort(hdfs.Spec.Version)),
 	}, Property{
 		Name:  "dfs.namenode.shared.edits.dir",
 		Value: editsDir,
@@ -156,16 +228,27 @@ func RenderHdfsSiteCfg(hdfs hdfsv1.HDFS) ([]byte, error) {
 		Name:  "dfs.datanode.data.dir",
 		Value: dataDirs,
 	})
-	for _, cfg := range hdfs.Spec.HdfsSite {
+	if PodNetwork(hdfs) {
+		// pod IPs change with each restart, names in the headless Services do not
 		c.Configuration = append(c.Configuration, Property{
-			Name:  cfg.Property,
-			Value: cfg.Value,
+			Name:  "dfs.client.use.datanode.hostname",
+			Value: "true",
+		}, Property{
+			Name:  "dfs.datanode.use.datanode.hostname",
+			Value: "true",
 		})
 	}
+	// datanodes of decommissioned or scaled down pools are listed in the exclude file
+	c.Configuration = append(c.Configuration, Property{
+		Name:  "dfs.hosts.exclude",
+		Value: ExcludeConfigMountPath + "/" + ExcludeFileName,
+	})
+	c.appendClusterConfigs(hdfs.Spec.HdfsSite)
+	c.appendExternal(HdfsSiteFileName, ext)
 	return xml.MarshalIndent(c, " ", " ")
 }
 
-func RenderMapredSiteCfg(cfgs []hdfsv1.ClusterConfig) ([]byte, error) {
+func RenderMapredSiteCfg(hdfs hdfsv1.HDFS, ext ExternalConfig) ([]byte, error) {
 
 	var c = Configuration{}
 
@@ -174,25 +257,61 @@ func RenderMapredSiteCfg(cfgs []hdfsv1.ClusterConfig) ([]byte, error) {
 		Value: "yarn",
 	},
 	)
-	for _, cfg := range cfgs {
+	if hdfs.Spec.Yarn.HistoryServer != nil {
+		host := YarnServerHost(hdfs, JobHistorySuffix)
 		c.Configuration = append(c.Configuration, Property{
-			Name:  cfg.Property,
-			Value: cfg.Value,
+			Name:  "mapreduce.jobhistory.address",
+			Value: fmt.Sprintf("%s:%d", host, JobHistoryPort),
+		}, Property{
+			Name:  "mapreduce.jobhistory.webapp.address",
+			Value: fmt.Sprintf("%s:%d", host, JobHistoryWebPort),
+		}, Property{
+			Name:  "mapreduce.jobhistory.recovery.enable",
+			Value: "true",
+		}, Property{
+			Name:  "mapreduce.jobhistory.recovery.store.class",
+			Value: "org.apache.hadoop.mapreduce.v2.hs.HistoryServerLeveldbStateStoreService",
+		}, Property{
+			Name:  "mapreduce.jobhistory.recovery.store.leveldb.path",
+			Value: YarnServerStatePath,
 		})
 	}
+	c.appendClusterConfigs(hdfs.Spec.Yarn.MapredSite)
+	c.appendExternal(MapredSiteFileName, ext)
 	return xml.MarshalIndent(c, " ", " ")
 }
 
-func RenderYarnSiteCfg(hdfs hdfsv1.HDFS) ([]byte, error) {
+func RenderYarnSiteCfg(hdfs hdfsv1.HDFS, ext ExternalConfig) ([]byte, error) {
 
 	var c = Configuration{}
 
+	nmHeapPercent := NMHeapPercent(hdfs.Spec.Yarn.NMHeapPercent)
+	nmContainerPercent := NMContainerPercent(hdfs.Spec.Yarn.NMContainerPercent)
+	if nmHeapPercent+nmContainerPercent > 100 {
+		return nil, fmt.Errorf("yarn: nmHeapPercent %d and nmContainerPercent %d exceed 100", nmHeapPercent, nmContainerPercent)
+	}
+
 	rmPrefix := GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-rm"
 	rmService := rmPrefix+"."+hdfs.Namespace+".svc.cluster.local"
 
+	quorum := ZkQuorum(hdfs)
+	if hdfs.Spec.Yarn.RMReplicas > 1 {
+		if quorum == "" {
+			return nil, fmt.Errorf("yarn: %d ResourceManagers need a ZooKeeper quorum", hdfs.Spec.Yarn.RMReplicas)
+		}
+		c.Configuration = append(c.Configuration, rmHAConfigs(hdfs, rmPrefix, rmService)...)
+	} else {
+		c.Configuration = append(c.Configuration, Property{
+			Name:  "yarn.resourcemanager.hostname",
+			Value: rmPrefix + "-0." + rmService,
+		})
+	}
 	c.Configuration = append(c.Configuration, Property{
-		Name:  "yarn.resourcemanager.hostname",
-		Value: rmPrefix+"-0." + rmService,
+		Name:  "yarn.nodemanager.local-dirs",
+		Value: NMLocalDirsPath,
+	}, Property{
+		Name:  "yarn.nodemanager.log-dirs",
+		Value: NMLogDirsPath,
 	}, Property{
 		Name:  "yarn.nodemanager.vmem-check-enabled",
 		Value: "false",
@@ -207,11 +326,127 @@ func RenderYarnSiteCfg(hdfs hdfsv1.HDFS) ([]byte, error) {
 		Value: "/var/log/hadoop-yarn/apps",
 	},
 	)
-	for _, cfg := range hdfs.Spec.Yarn.YarnSite {
+	// the ResourceManager keeps its state and elects its leader in the cluster ZooKeeper
+	if quorum != "" {
+		zkAddress := "yarn.resourcemanager.zk-address"
+		if IsHadoop3(hdfs.Spec.Version) {
+			zkAddress = "hadoop.zk.address"
+		}
 		c.Configuration = append(c.Configuration, Property{
-			Name:  cfg.Property,
-			Value: cfg.Value,
+			Name:  zkAddress,
+			Value: quorum,
 		})
 	}
+	if LogAggregation(hdfs) {
+		c.Configuration = append(c.Configuration, Property{
+			Name:  "yarn.log-aggregation-enable",
+			Value: "true",
+		})
+		// the JobHistory server serves the aggregated logs
+		if hdfs.Spec.Yarn.HistoryServer != nil {
+			c.Configuration = append(c.Configuration, Property{
+				Name:  "yarn.log.server.url",
+				Value: fmt.Sprintf("http://%s:%d/jobhistory/logs", YarnServerHost(hdfs, JobHistorySuffix), JobHistoryWebPort),
+			})
+		}
+	}
+	if hdfs.Spec.Yarn.TimelineServer != nil {
+		c.Configuration = append(c.Configuration, timelineConfigs(hdfs)...)
+	}
+	// the autoscaler decommissions the NodeManagers through the exclude file before removing them
+	if hdfs.Spec.Yarn.Autoscaling != nil {
+		c.Configuration = append(c.Configuration, Property{
+			Name:  "yarn.resourcemanager.nodes.exclude-path",
+			Value: NMExcludeConfigMountPath + "/" + NMExcludeFileName,
+		})
+	}
+	// offer the NodeManager container resources to YARN, user yarnSite entries still take precedence
+	if memoryMB, ok := MemoryShareMB(hdfs.Spec.Yarn.NMResources, nmContainerPercent); ok {
+		c.Configuration = append(c.Configuration, Property{
+			Name:  "yarn.nodemanager.resource.memory-mb",
+			Value: strconv.FormatInt(memoryMB, 10),
+		})
+	}
+	if vcores, ok := VCores(hdfs.Spec.Yarn.NMResources); ok {
+		c.Configuration = append(c.Configuration, Property{
+			Name:  "yarn.nodemanager.resource.cpu-vcores",
+			Value: strconv.FormatInt(vcores, 10),
+		})
+	}
+	c.appendClusterConfigs(hdfs.Spec.Yarn.YarnSite)
+	c.appendExternal(YarnSiteFileName, ext)
 	return xml.MarshalIndent(c, " ", " ")
 }
+
+// rmHAConfigs lists every ResourceManager pod as rm<ordinal>, each finding its own id by the
+// address its host name resolves to. The active one is elected and keeps the applications state
+// in ZooKeeper, for a new active one to recover them.
+func rmHAConfigs(hdfs hdfsv1.HDFS, rmPrefix, rmService string) []Property {
+	var ids []string
+	var props []Property
+	for i := int32(0); i < hdfs.Spec.Yarn.RMReplicas; i++ {
+		id := fmt.Sprintf("rm%d", i)
+		host := fmt.Sprintf("%s-%d.%s", rmPrefix, i, rmService)
+		ids = append(ids, id)
+		props = append(props, Property{
+			Name:  "yarn.resourcemanager.hostname." + id,
+			Value: host,
+		}, Property{
+			Name:  "yarn.resourcemanager.webapp.address." + id,
+			Value: host + ":8088",
+		})
+	}
+	return append(props, Property{
+		Name:  "yarn.resourcemanager.ha.enabled",
+		Value: "true",
+	}, Property{
+		Name:  "yarn.resourcemanager.ha.rm-ids",
+		Value: strings.Join(ids, ","),
+	}, Property{
+		Name:  "yarn.resourcemanager.cluster-id",
+		Value: rmPrefix,
+	}, Property{
+		Name:  "yarn.resourcemanager.ha.automatic-failover.enabled",
+		Value: "true",
+	}, Property{
+		Name:  "yarn.resourcemanager.recovery.enabled",
+		Value: "true",
+	}, Property{
+		Name:  "yarn.resourcemanager.store.class",
+		Value: "org.apache.hadoop.yarn.server.resourcemanager.recovery.ZKRMStateStore",
+	})
+}
+
+// timelineConfigs have the ResourceManager publish the applications history to the timeline
+// server, which keeps it in leveldb.
+func timelineConfigs(hdfs hdfsv1.HDFS) []Property {
+	publisher := "yarn.system-metrics-publisher.enabled"
+	if !IsHadoop3(hdfs.Spec.Version) {
+		publisher = "yarn.resourcemanager.system-metrics-publisher.enabled"
+	}
+	return []Property{{
+		Name:  "yarn.timeline-service.enabled",
+		Value: "true",
+	}, {
+		Name:  "yarn.timeline-service.hostname",
+		Value: YarnServerHost(hdfs, TimelineSuffix),
+	}, {
+		Name:  "yarn.timeline-service.address",
+		Value: fmt.Sprintf("%s:%d", YarnServerHost(hdfs, TimelineSuffix), TimelinePort),
+	}, {
+		Name:  "yarn.timeline-service.webapp.address",
+		Value: fmt.Sprintf("%s:%d", YarnServerHost(hdfs, TimelineSuffix), TimelineWebPort),
+	}, {
+		Name:  "yarn.timeline-service.generic-application-history.enabled",
+		Value: "true",
+	}, {
+		Name:  "yarn.timeline-service.leveldb-timeline-store.path",
+		Value: YarnServerStatePath,
+	}, {
+		Name:  "yarn.timeline-service.leveldb-state-store.path",
+		Value: YarnServerStatePath,
+	}, {
+		Name:  publisher,
+		Value: "true",
+	}}
+}
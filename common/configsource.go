@@ -0,0 +1,75 @@
+package common
+
+import (
+	"encoding/xml"
+	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+// SiteFileNames are the site files that can be overlaid from referenced ConfigMaps and Secrets.
+var SiteFileNames = []string{CoreSiteFileName, HdfsSiteFileName, MapredSiteFileName, YarnSiteFileName}
+
+// ExternalConfig holds the properties resolved from the ConfigMaps and Secrets
+// referenced by the HDFS spec, keyed by site file name.
+type ExternalConfig struct {
+	// Plain properties are rendered into the common config map.
+	Plain map[string][]Property
+	// Secret properties are rendered into the secret config and XIncluded by the site file.
+	Secret map[string][]Property
+}
+
+// NewExternalConfig returns an empty ExternalConfig.
+func NewExternalConfig() ExternalConfig {
+	return ExternalConfig{
+		Plain:  map[string][]Property{},
+		Secret: map[string][]Property{},
+	}
+}
+
+// SecretSiteFileName returns the name of the file holding the secret part of a site file,
+// e.g. core-site-secret.xml for core-site.xml.
+func SecretSiteFileName(file string) string {
+	return strings.TrimSuffix(file, ".xml") + "-secret.xml"
+}
+
+// ParseConfiguration reads the properties of a Hadoop configuration XML file.
+func ParseConfiguration(data []byte) ([]Property, error) {
+	var c struct {
+		Properties []Property `xml:"property"`
+	}
+	if err := xml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return c.Properties, nil
+}
+
+// BuildSecretConfig renders the secret part of every site file into a Secret, mounted
+// next to the common config map through a projected volume.
+func BuildSecretConfig(hdfs hdfsv1.HDFS, name string, ext ExternalConfig) (corev1.Secret, error) {
+	data := map[string][]byte{}
+	for _, file := range SiteFileNames {
+		if len(ext.Secret[file]) == 0 {
+			continue
+		}
+		rendered, err := xml.MarshalIndent(Configuration{Configuration: ext.Secret[file]}, " ", " ")
+		if err != nil {
+			return corev1.Secret{}, err
+		}
+		data[SecretSiteFileName(file)] = rendered
+	}
+	return corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            name,
+			OwnerReferences: GetOwnerReference(hdfs),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}, nil
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// SpecHashAnnotation holds the hash of the spec an object was built from, so that changes can be
+// detected without comparing against the fields defaulted by the api server.
+const SpecHashAnnotation = "qy.dataworkbench.com/spec-hash"
+
+// SpecHash returns a short hash of the json representation of the given spec
+func SpecHash(spec interface{}) string {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
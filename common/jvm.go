@@ -0,0 +1,137 @@
+package common
+
+import (
+	"fmt"
+	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"strings"
+)
+
+const (
+	DefaultHeapPercent        = 75
+	DefaultNMHeapPercent      = 15
+	DefaultNMContainerPercent = 75
+)
+
+// Daemon roles, as named in the Hadoop *_OPTS environment variables
+const (
+	NamenodeRole        = "NAMENODE"
+	DatanodeRole        = "DATANODE"
+	JournalnodeRole     = "JOURNALNODE"
+	ResourcemanagerRole = "RESOURCEMANAGER"
+	NodemanagerRole     = "NODEMANAGER"
+	HistoryserverRole   = "HISTORYSERVER"
+	TimelineserverRole  = "TIMELINESERVER"
+)
+
+// IsHadoop3 returns true for Hadoop 3.x versions
+func IsHadoop3(version string) bool {
+	return strings.HasPrefix(version, "3")
+}
+
+// OptsEnvName returns the name of the environment variable holding the JVM options of a daemon.
+// Hadoop 3 renamed HADOOP_<ROLE>_OPTS to HDFS_<ROLE>_OPTS for the HDFS daemons; YARN ones kept their name.
+func OptsEnvName(version string, role string) string {
+	switch role {
+	case ResourcemanagerRole, NodemanagerRole, TimelineserverRole:
+		return "YARN_" + role + "_OPTS"
+	case HistoryserverRole:
+		if IsHadoop3(version) {
+			return "MAPRED_HISTORYSERVER_OPTS"
+		}
+		return "HADOOP_JOB_HISTORYSERVER_OPTS"
+	}
+	if IsHadoop3(version) {
+		return "HDFS_" + role + "_OPTS"
+	}
+	return "HADOOP_" + role + "_OPTS"
+}
+
+// memoryOf returns the memory limit of the container, or its request when there is no limit.
+func memoryOf(resources corev1.ResourceRequirements) (resource.Quantity, bool) {
+	if q, ok := resources.Limits[corev1.ResourceMemory]; ok && !q.IsZero() {
+		return q, true
+	}
+	if q, ok := resources.Requests[corev1.ResourceMemory]; ok && !q.IsZero() {
+		return q, true
+	}
+	return resource.Quantity{}, false
+}
+
+// percentOrDefault returns percent, or def when it is unset
+func percentOrDefault(percent int32, def int32) int32 {
+	if percent <= 0 {
+		return def
+	}
+	return percent
+}
+
+// ValidateHeapPercents checks the memory shares of the spec are between 1 and 100, 0 leaving the default
+func ValidateHeapPercents(spec hdfsv1.HDFSSpec) error {
+	percents := map[string]int32{
+		"namenode.heapPercent":    spec.Namenode.HeapPercent,
+		"journalnode.heapPercent": spec.Journalnode.HeapPercent,
+		"datanode.heapPercent":    spec.Datanode.HeapPercent,
+		"yarn.rmHeapPercent":      spec.Yarn.RMHeapPercent,
+		"yarn.nmHeapPercent":      spec.Yarn.NMHeapPercent,
+		"yarn.nmContainerPercent": spec.Yarn.NMContainerPercent,
+	}
+	for _, pool := range spec.DatanodePools {
+		percents["datanodePools."+pool.Name+".heapPercent"] = pool.HeapPercent
+	}
+	for field, percent := range percents {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("%s %d is not between 1 and 100", field, percent)
+		}
+	}
+	return nil
+}
+
+// MemoryShareMB returns the given percentage of the container memory in MiB, false if the
+// container has no memory limit or request.
+func MemoryShareMB(resources corev1.ResourceRequirements, percent int32) (int64, bool) {
+	q, ok := memoryOf(resources)
+	if !ok {
+		return 0, false
+	}
+	return q.Value() * int64(percent) / 100 / (1024 * 1024), true
+}
+
+// HeapEnvVars returns the *_OPTS environment variable sizing the daemon heap from the container
+// memory. Nothing is returned without memory resources so that the image defaults apply.
+func HeapEnvVars(version string, role string, resources corev1.ResourceRequirements, heapPercent int32) []corev1.EnvVar {
+	heapMB, ok := MemoryShareMB(resources, percentOrDefault(heapPercent, DefaultHeapPercent))
+	if !ok || heapMB <= 0 {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: OptsEnvName(version, role), Value: fmt.Sprintf("-Xms%dm -Xmx%dm", heapMB, heapMB)},
+	}
+}
+
+// NMHeapPercent returns the share of the NodeManager memory given to its heap
+func NMHeapPercent(percent int32) int32 {
+	return percentOrDefault(percent, DefaultNMHeapPercent)
+}
+
+// NMContainerPercent returns the share of the NodeManager memory offered to YARN containers
+func NMContainerPercent(percent int32) int32 {
+	return percentOrDefault(percent, DefaultNMContainerPercent)
+}
+
+// VCores returns the whole cpus of the container limit, at least 1, false without a cpu limit or request.
+func VCores(resources corev1.ResourceRequirements) (int64, bool) {
+	q, ok := resources.Limits[corev1.ResourceCPU]
+	if !ok || q.IsZero() {
+		q, ok = resources.Requests[corev1.ResourceCPU]
+	}
+	if !ok || q.IsZero() {
+		return 0, false
+	}
+	cores := q.MilliValue() / 1000
+	if cores < 1 {
+		cores = 1
+	}
+	return cores, true
+}
@@ -14,6 +14,18 @@ const (
 	ClusterNameLabelName = "dataomnis.io/cluster-name"
 	Type                 = "hdfs"
 	StatefulSetLabel     = "dataomnis.io/statefulset-name"
+	JobClusterLabelName  = "dataomnis.io/job-cluster"
+
+	// HAStateLabelName is set on the namenode and ResourceManager pods with their HA state, for
+	// Services to select the active one.
+	HAStateLabelName = "dataomnis.io/ha-state"
+	HAStateActive    = "active"
+
+	// YarnClusterLabelName is set on the resources of a YarnCluster, with its name
+	YarnClusterLabelName = "dataomnis.io/yarn-cluster"
+
+	// DatanodePoolLabelName is set on the StatefulSets of the datanode pools, with the pool name
+	DatanodePoolLabelName = "dataomnis.io/datanode-pool"
 )
 
 // ExtractNamespacedName returns an NamespacedName based on the given Object.
@@ -38,7 +50,16 @@ func NewLabels(hdfs types.NamespacedName) map[string]string {
 	}
 }
 
-// HeadlessService returns a headless service for the given StatefulSet
+// NewJobPodLabels labels the pods of a Job run against the cluster, named after the Job.
+func NewJobPodLabels(hdfs types.NamespacedName, job types.NamespacedName) map[string]string {
+	lbls := NewLabels(job)
+	lbls[JobClusterLabelName] = hdfs.Name
+	return lbls
+}
+
+// HeadlessService returns a headless service for the given StatefulSet. Addresses of pods not
+// ready yet are published as the daemons reach each other by pod name while starting, e.g. a
+// namenode in safemode waiting for the block reports of the datanodes.
 func HeadlessService(hdfs v1.HDFS, ssetName string, ports []corev1.ServicePort) corev1.Service {
 	nsn := ExtractNamespacedName(&hdfs)
 	return corev1.Service{
@@ -53,10 +74,11 @@ func HeadlessService(hdfs v1.HDFS, ssetName string, ports []corev1.ServicePort)
 			OwnerReferences: GetOwnerReference(hdfs),
 		},
 		Spec: corev1.ServiceSpec{
-			Type:      corev1.ServiceTypeClusterIP,
-			ClusterIP: corev1.ClusterIPNone,
-			Selector:  NewStatefulSetLabels(nsn, ssetName),
-			Ports:     ports,
+			Type:                     corev1.ServiceTypeClusterIP,
+			ClusterIP:                corev1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Selector:                 NewStatefulSetLabels(nsn, ssetName),
+			Ports:                    ports,
 		},
 	}
 }
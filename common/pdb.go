@@ -0,0 +1,40 @@
+package common
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DefaultMaxUnavailable is the number of pods of a role a voluntary disruption may take down at once.
+var DefaultMaxUnavailable = intstr.FromInt(1)
+
+// QuorumMaxUnavailable returns the number of members of a quorum of the given size that can be
+// down while a majority remains, e.g. 1 of 3 journalnodes.
+func QuorumMaxUnavailable(replicas int32) intstr.IntOrString {
+	return intstr.FromInt(int((replicas - 1) / 2))
+}
+
+// PodDisruptionBudget returns the PodDisruptionBudget of the given StatefulSet
+func PodDisruptionBudget(hdfs v1.HDFS, ssetName string, maxUnavailable intstr.IntOrString) policyv1.PodDisruptionBudget {
+	nsn := ExtractNamespacedName(&hdfs)
+	return policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       nsn.Namespace,
+			Name:            ssetName,
+			Labels:          NewStatefulSetLabels(nsn, ssetName),
+			OwnerReferences: GetOwnerReference(hdfs),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: NewStatefulSetLabels(nsn, ssetName),
+			},
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -109,6 +110,65 @@ func NewConfigMapVolume(configMapName, name, mountPath string) ConfigMapVolume {
 	}
 }
 
+// ProjectedConfigVolume defines a volume exposing a configmap and a secret in the same directory
+type ProjectedConfigVolume struct {
+	configMapName string
+	secretName    string
+	name          string
+	mountPath     string
+}
+
+// Volume returns the k8s volume.
+func (pv ProjectedConfigVolume) Volume() corev1.Volume {
+	secretOptional := true
+	return corev1.Volume{
+		Name: pv.name,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: pv.configMapName},
+							Optional:             &defaultOptional,
+						},
+					},
+					{
+						Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: pv.secretName},
+							Optional:             &secretOptional,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// VolumeMount returns the k8s volume mount.
+func (pv ProjectedConfigVolume) VolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      pv.name,
+		MountPath: pv.mountPath,
+		ReadOnly:  true,
+	}
+}
+
+// NewHdfsConfigVolume creates the volume exposing the common config map and the secret config
+// of the given HDFS cluster
+func NewHdfsConfigVolume(hdfsName, name, mountPath string) ProjectedConfigVolume {
+	return NewProjectedConfigVolume(GetName(hdfsName, CommonConfigName), GetName(hdfsName, SecretConfigName), name, mountPath)
+}
+
+// NewProjectedConfigVolume creates the volume exposing the given config map and secret
+func NewProjectedConfigVolume(configMapName, secretName, name, mountPath string) ProjectedConfigVolume {
+	return ProjectedConfigVolume{
+		configMapName: configMapName,
+		secretName:    secretName,
+		name:          name,
+		mountPath:     mountPath,
+	}
+}
+
 // PodTemplateBuilder helps with building a pod template inheriting values
 // from a user-provided pod template. It focuses on building a pod with
 // one main Container.
@@ -123,6 +183,11 @@ func (b *PodTemplateBuilder) WithContainers(container corev1.Container) *PodTemp
 	return b
 }
 
+func (b *PodTemplateBuilder) WithInitContainers(containers ...corev1.Container) *PodTemplateBuilder {
+	b.PodTemplate.Spec.InitContainers = append(b.PodTemplate.Spec.InitContainers, containers...)
+	return b
+}
+
 // WithSpecVolumes appends the given volumes to the Container, unless already provided in the template.
 func (b *PodTemplateBuilder) WithSpecVolumes(volumes ...corev1.Volume) *PodTemplateBuilder {
 	for _, v := range volumes {
@@ -164,6 +229,20 @@ func (b *PodTemplateBuilder) WithHostNetwork(hostNetwork bool) *PodTemplateBuild
 	return b
 }
 
+// WithNetwork runs the pods on the host network, unless the cluster uses the pod network.
+func (b *PodTemplateBuilder) WithNetwork(hdfs hdfsv1.HDFS) *PodTemplateBuilder {
+	if PodNetwork(hdfs) {
+		return b
+	}
+	return b.WithHostNetwork(true).WithDNSPolicy(corev1.DNSClusterFirstWithHostNet)
+}
+
+// WithPodSecurity restricts the pod and the containers added so far, see RestrictPodSpec.
+func (b *PodTemplateBuilder) WithPodSecurity(hdfs hdfsv1.HDFS) *PodTemplateBuilder {
+	RestrictPodSpec(hdfs, &b.PodTemplate.Spec)
+	return b
+}
+
 func (b *PodTemplateBuilder) WithHostPID(hostPID bool) *PodTemplateBuilder {
 	b.PodTemplate.Spec.HostPID = hostPID
 	return b
@@ -173,3 +252,54 @@ func (b *PodTemplateBuilder) WithTemplateMetadata(labels map[string]string) *Pod
 	b.PodTemplate.Labels = labels
 	return b
 }
+
+func (b *PodTemplateBuilder) WithNodeSelector(nodeSelector map[string]string) *PodTemplateBuilder {
+	if len(b.PodTemplate.Spec.NodeSelector) == 0 {
+		b.PodTemplate.Spec.NodeSelector = nodeSelector
+	}
+	return b
+}
+
+func (b *PodTemplateBuilder) WithTolerations(tolerations ...corev1.Toleration) *PodTemplateBuilder {
+	b.PodTemplate.Spec.Tolerations = append(b.PodTemplate.Spec.Tolerations, tolerations...)
+	return b
+}
+
+// WithDefaultAntiAffinity prefers spreading the pods matching the labels over distinct nodes,
+// unless an affinity is already provided.
+func (b *PodTemplateBuilder) WithDefaultAntiAffinity(labels map[string]string) *PodTemplateBuilder {
+	if b.PodTemplate.Spec.Affinity != nil {
+		return b
+	}
+	b.PodTemplate.Spec.Affinity = &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+						TopologyKey:   corev1.LabelHostname,
+					},
+				},
+			},
+		},
+	}
+	return b
+}
+
+// WithDefaultTopologySpread spreads the pods matching the labels over the zones on a best effort basis,
+// unless topology spread constraints are already provided.
+func (b *PodTemplateBuilder) WithDefaultTopologySpread(labels map[string]string) *PodTemplateBuilder {
+	if len(b.PodTemplate.Spec.TopologySpreadConstraints) > 0 {
+		return b
+	}
+	b.PodTemplate.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+	return b
+}
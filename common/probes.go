@@ -0,0 +1,78 @@
+package common
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// RoleProbes are the default probes of the main container of a role
+type RoleProbes struct {
+	Startup   corev1.Probe
+	Readiness corev1.Probe
+	Liveness  corev1.Probe
+}
+
+// WithProbes sets the probes of the container, overriding the defaults with the thresholds of the spec.
+func (r RoleProbes) WithProbes(container corev1.Container, spec *v1.Probes) corev1.Container {
+	if spec == nil {
+		spec = &v1.Probes{}
+	}
+	container.StartupProbe = withThresholds(r.Startup, spec.Startup)
+	container.ReadinessProbe = withThresholds(r.Readiness, spec.Readiness)
+	container.LivenessProbe = withThresholds(r.Liveness, spec.Liveness)
+	return container
+}
+
+func withThresholds(probe corev1.Probe, thresholds *v1.ProbeThresholds) *corev1.Probe {
+	if thresholds != nil {
+		if thresholds.InitialDelaySeconds != nil {
+			probe.InitialDelaySeconds = *thresholds.InitialDelaySeconds
+		}
+		if thresholds.PeriodSeconds != nil {
+			probe.PeriodSeconds = *thresholds.PeriodSeconds
+		}
+		if thresholds.TimeoutSeconds != nil {
+			probe.TimeoutSeconds = *thresholds.TimeoutSeconds
+		}
+		if thresholds.FailureThreshold != nil {
+			probe.FailureThreshold = *thresholds.FailureThreshold
+		}
+	}
+	return &probe
+}
+
+// TCPProbe checks the given port accepts connections
+func TCPProbe(port int, periodSeconds, failureThreshold int32) corev1.Probe {
+	return corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)},
+		},
+		PeriodSeconds:    periodSeconds,
+		TimeoutSeconds:   5,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+// HTTPCheckProbe checks the body of a local HTTP endpoint matches all the given extended regular
+// expressions, e.g. a JMX bean attribute.
+func HTTPCheckProbe(port int, path string, periodSeconds, failureThreshold int32, patterns ...string) corev1.Probe {
+	check := fmt.Sprintf("_BODY=$(curl -sf 'http://localhost:%d%s')", port, path)
+	for _, pattern := range patterns {
+		check += fmt.Sprintf(" && echo \"$_BODY\" | grep -Eq '%s'", pattern)
+	}
+	return corev1.Probe{
+		Handler: corev1.Handler{
+			Exec: &corev1.ExecAction{Command: []string{"bash", "-c", check}},
+		},
+		PeriodSeconds:    periodSeconds,
+		TimeoutSeconds:   10,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+// JMXQuery returns the path of the JMX servlet answering the given bean
+func JMXQuery(bean string) string {
+	return "/jmx?qry=" + bean
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultRunAsUser is the user the pods run as on the pod network
+	DefaultRunAsUser int64 = 1000
+
+	// PodNetworkHadoopUser is the Hadoop user of the daemons on the pod network, set with
+	// HADOOP_USER_NAME as the uid they run as may have no name in the image. The namenodes
+	// make it the HDFS superuser.
+	PodNetworkHadoopUser = "hdfs"
+	// HostNetworkHadoopUser is the Hadoop user of the daemons on the host network, which run as root
+	HostNetworkHadoopUser = "root"
+)
+
+// HadoopUser returns the HDFS superuser of the cluster, the operator acts as
+func HadoopUser(hdfs v1.HDFS) string {
+	if PodNetwork(hdfs) {
+		return PodNetworkHadoopUser
+	}
+	return HostNetworkHadoopUser
+}
+
+// PodNetwork returns true if the daemons of the cluster run on the pod network
+func PodNetwork(hdfs v1.HDFS) bool {
+	return hdfs.Spec.Network != nil && hdfs.Spec.Network.Mode == v1.NetworkModePod
+}
+
+// RestrictPodSpec sets the security contexts required by the restricted Pod Security Standard
+// on the pod and all its containers, for clusters on the pod network. The containers act as
+// the Hadoop user of the cluster.
+func RestrictPodSpec(hdfs v1.HDFS, spec *corev1.PodSpec) {
+	if !PodNetwork(hdfs) {
+		return
+	}
+	user := DefaultRunAsUser
+	if hdfs.Spec.Network.RunAsUser != nil {
+		user = *hdfs.Spec.Network.RunAsUser
+	}
+	runAsNonRoot := true
+	spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsNonRoot:   &runAsNonRoot,
+		RunAsUser:      &user,
+		RunAsGroup:     &user,
+		FSGroup:        &user,
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	for i := range spec.InitContainers {
+		restrictContainer(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		restrictContainer(&spec.Containers[i])
+	}
+}
+
+func restrictContainer(container *corev1.Container) {
+	container.SecurityContext = restrictedContainerSecurityContext()
+	for _, env := range container.Env {
+		if env.Name == "HADOOP_USER_NAME" {
+			return
+		}
+	}
+	container.Env = append(container.Env, corev1.EnvVar{Name: "HADOOP_USER_NAME", Value: PodNetworkHadoopUser})
+}
+
+func restrictedContainerSecurityContext() *corev1.SecurityContext {
+	allowPrivilegeEscalation := false
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+}
@@ -0,0 +1,158 @@
+package common_test
+
+import (
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/backup"
+	"github.com/dataworkbench/hdfs-operator/controllers/datanode"
+	"github.com/dataworkbench/hdfs-operator/controllers/decommission"
+	"github.com/dataworkbench/hdfs-operator/controllers/journalnode"
+	"github.com/dataworkbench/hdfs-operator/controllers/namenode"
+	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	"github.com/dataworkbench/hdfs-operator/controllers/zookeeper"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podModeHDFS enables every component running pods, on the pod network
+func podModeHDFS() v1.HDFS {
+	storage := v1.BackupStorage{PVC: &v1.PVCStorage{ClaimName: "backups"}}
+	return v1.HDFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "default"},
+		Spec: v1.HDFSSpec{
+			Version:     "3.2.2",
+			Image:       "hadoop:3.2.2",
+			Network:     &v1.Network{Mode: v1.NetworkModePod},
+			Namenode:    v1.NamenodeSet{Name: "nn", Replicas: 2, Capacity: "10Gi"},
+			Journalnode: v1.Journalnode{Name: "jn", Replicas: 3, Capacity: "1Gi"},
+			Datanode:    v1.Datanode{Name: "dn", Replicas: 3, Capacity: "100Gi"},
+			DatanodePools: []v1.DatanodePool{
+				{Datanode: v1.Datanode{Name: "ssd", Replicas: 2, Capacity: "100Gi"}},
+			},
+			Zookeeper: &v1.Zookeeper{Replicas: 3, Capacity: "1Gi"},
+			Yarn: v1.Yarn{
+				Name:           "yarn",
+				RMReplicas:     2,
+				NMReplicas:     2,
+				HistoryServer:  &v1.YarnServer{},
+				TimelineServer: &v1.YarnServer{},
+			},
+			Backup:  &v1.Backup{BackupStorage: storage},
+			Restore: &v1.Restore{BackupStorage: storage, BackupName: "hdfs-20210730-100000"},
+		},
+	}
+}
+
+// podSpecs builds the pod spec of every StatefulSet, Job and CronJob of the cluster
+func podSpecs(t *testing.T, hdfs v1.HDFS) map[string]corev1.PodSpec {
+	var ssets []appsv1.StatefulSet
+	for _, build := range []func(v1.HDFS) (appsv1.StatefulSet, error){
+		namenode.BuildStatefulSet, journalnode.BuildStatefulSet, datanode.BuildStatefulSet,
+		zookeeper.BuildStatefulSet, yarn.BuildRMStatefulSet, yarn.BuildNMStatefulSet,
+	} {
+		sset, err := build(hdfs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ssets = append(ssets, sset)
+	}
+	pools, err := datanode.BuildPoolStatefulSets(hdfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers, err := yarn.BuildServerStatefulSets(hdfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssets = append(append(ssets, pools...), servers...)
+
+	specs := map[string]corev1.PodSpec{}
+	for _, sset := range ssets {
+		specs[sset.Name] = sset.Spec.Template.Spec
+	}
+	refresh := decommission.BuildRefreshNodesJob(hdfs, "")
+	specs[refresh.Name] = refresh.Spec.Template.Spec
+	nmRefresh := yarn.BuildRefreshNodesJob(hdfs, "")
+	specs[nmRefresh.Name] = nmRefresh.Spec.Template.Spec
+	cj, err := backup.BuildCronJob(hdfs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs[cj.Name] = cj.Spec.JobTemplate.Spec.Template.Spec
+	return specs
+}
+
+func TestPodModeTemplatesAreRestricted(t *testing.T) {
+	hdfs := podModeHDFS()
+	specs := podSpecs(t, hdfs)
+	if nn := specs["hdfs-nn"]; len(nn.InitContainers) == 0 {
+		t.Fatal("expected the restore init container on the namenodes")
+	}
+
+	for name, spec := range specs {
+		if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+			t.Errorf("%s: uses a host namespace", name)
+		}
+		for _, volume := range spec.Volumes {
+			if volume.HostPath != nil {
+				t.Errorf("%s: host path volume %s", name, volume.Name)
+			}
+		}
+		pod := spec.SecurityContext
+		if pod == nil || pod.RunAsNonRoot == nil || !*pod.RunAsNonRoot {
+			t.Errorf("%s: pod not running as non root", name)
+		}
+		if pod == nil || pod.SeccompProfile == nil || pod.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+			t.Errorf("%s: no RuntimeDefault seccomp profile", name)
+		}
+		for _, container := range append(spec.InitContainers, spec.Containers...) {
+			checkRestrictedContainer(t, name, container)
+		}
+	}
+}
+
+func checkRestrictedContainer(t *testing.T, pod string, container corev1.Container) {
+	sc := container.SecurityContext
+	if sc == nil {
+		t.Errorf("%s/%s: no security context", pod, container.Name)
+		return
+	}
+	if sc.Privileged != nil && *sc.Privileged {
+		t.Errorf("%s/%s: privileged", pod, container.Name)
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		t.Errorf("%s/%s: privilege escalation allowed", pod, container.Name)
+	}
+	if sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot || sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		t.Errorf("%s/%s: runs as root", pod, container.Name)
+	}
+	if sc.Capabilities == nil || len(sc.Capabilities.Drop) != 1 || sc.Capabilities.Drop[0] != "ALL" {
+		t.Errorf("%s/%s: capabilities not dropped", pod, container.Name)
+	}
+	if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+		t.Errorf("%s/%s: adds capabilities %v", pod, container.Name, sc.Capabilities.Add)
+	}
+	user := ""
+	for _, env := range container.Env {
+		if env.Name == "HADOOP_USER_NAME" {
+			user = env.Value
+		}
+	}
+	if user != com.PodNetworkHadoopUser {
+		t.Errorf("%s/%s: HADOOP_USER_NAME %q, expected %q", pod, container.Name, user, com.PodNetworkHadoopUser)
+	}
+}
+
+func TestHadoopUser(t *testing.T) {
+	hdfs := podModeHDFS()
+	if user := com.HadoopUser(hdfs); user != com.PodNetworkHadoopUser {
+		t.Errorf("pod network: expected %s, got %s", com.PodNetworkHadoopUser, user)
+	}
+	hdfs.Spec.Network = nil
+	if user := com.HadoopUser(hdfs); user != com.HostNetworkHadoopUser {
+		t.Errorf("host network: expected %s, got %s", com.HostNetworkHadoopUser, user)
+	}
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"fmt"
+	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
+)
+
+// YARN servers, named after the YARN cluster with these suffixes
+const (
+	JobHistorySuffix = "-jhs"
+	TimelineSuffix   = "-timeline"
+
+	JobHistoryPort    = 10020
+	JobHistoryWebPort = 19888
+	TimelinePort      = 10200
+	TimelineWebPort   = 8188
+
+	// YarnServerStatePath holds the leveldb state of the JobHistory and timeline servers
+	YarnServerStatePath = "/hadoop/yarn/state"
+
+	NMLocalDirsPath = "/hadoop/yarn/local"
+	NMLogDirsPath   = "/hadoop/yarn/logs"
+
+	// NMExcludeConfigMountPath holds the file listing the NodeManagers to decommission
+	NMExcludeConfigMountPath = "/etc/hadoop-yarn-exclude"
+	NMExcludeFileName        = "yarn.exclude"
+)
+
+// YarnServerName returns the name of the StatefulSet and headless Service of a YARN server
+func YarnServerName(hdfs hdfsv1.HDFS, suffix string) string {
+	return GetName(hdfs.Name, hdfs.Spec.Yarn.Name) + suffix
+}
+
+// YarnServerHost returns the address of the single pod of a YARN server
+func YarnServerHost(hdfs hdfsv1.HDFS, suffix string) string {
+	name := YarnServerName(hdfs, suffix)
+	return fmt.Sprintf("%s-0.%s.%s.svc.cluster.local", name, name, hdfs.Namespace)
+}
+
+// LogAggregation returns true unless the aggregation of the container logs is disabled
+func LogAggregation(hdfs hdfsv1.HDFS) bool {
+	return hdfs.Spec.Yarn.LogAggregation == nil || *hdfs.Spec.Yarn.LogAggregation
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	"strings"
+)
+
+const (
+	DefaultZookeeperName  = "zk"
+	DefaultZookeeperImage = "zookeeper:3.6.3"
+	ZookeeperClientPort   = 2181
+)
+
+// EmbeddedZookeeper returns whether the operator runs the ZooKeeper ensemble of the cluster
+func EmbeddedZookeeper(hdfs v1.HDFS) bool {
+	return hdfs.Spec.Zookeeper != nil && hdfs.Spec.ZkQuorum == ""
+}
+
+// ZookeeperName returns the name of the embedded ensemble StatefulSet and headless Service
+func ZookeeperName(hdfs v1.HDFS) string {
+	name := DefaultZookeeperName
+	if hdfs.Spec.Zookeeper != nil && hdfs.Spec.Zookeeper.Name != "" {
+		name = hdfs.Spec.Zookeeper.Name
+	}
+	return GetName(hdfs.Name, name)
+}
+
+// ZookeeperHost returns the address of the ordinal-th member of the embedded ensemble
+func ZookeeperHost(hdfs v1.HDFS, ordinal int32) string {
+	name := ZookeeperName(hdfs)
+	return fmt.Sprintf("%s-%d.%s.%s.svc.cluster.local", name, ordinal, name, hdfs.Namespace)
+}
+
+// ZkQuorum returns the ZooKeeper connection string of the cluster, the external one if given.
+func ZkQuorum(hdfs v1.HDFS) string {
+	if !EmbeddedZookeeper(hdfs) {
+		return hdfs.Spec.ZkQuorum
+	}
+	hosts := make([]string, 0, hdfs.Spec.Zookeeper.Replicas)
+	for i := int32(0); i < hdfs.Spec.Zookeeper.Replicas; i++ {
+		hosts = append(hosts, fmt.Sprintf("%s:%d", ZookeeperHost(hdfs, i), ZookeeperClientPort))
+	}
+	return strings.Join(hosts, ",")
+}
@@ -2,10 +2,15 @@ package controllers
 
 import (
 	"fmt"
+	"reflect"
 	hdfsv1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
 	"k8s.io/api/apps/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"time"
 )
@@ -25,51 +30,123 @@ func HandleUpscaleAndSpecChanges(c client.Client, hdfs hdfsv1.HDFS, res HdfsReso
 		}
 	}
 
+	for _,r := range res.Secrets{
+		if _, err := ReconcileSecret(c, r, &hdfs); err != nil {
+			return results, fmt.Errorf("reconcile Secret: %w", err)
+		}
+	}
+
 	for _,r := range res.Services{
 		if _, err := ReconcileService(c, &r, &hdfs); err != nil {
 			return results, fmt.Errorf("reconcile HeadlessService: %w", err)
 		}
 	}
 
+	for _,r := range res.PodDisruptionBudgets{
+		if _, err := ReconcilePodDisruptionBudget(c, r, &hdfs); err != nil {
+			return results, fmt.Errorf("reconcile PodDisruptionBudget: %w", err)
+		}
+	}
+
+	// the failover controllers of the namenodes need the ensemble up first
+	for _,r := range res.Zookeeper{
+		if _, err := ReconcileStatefulSet(c, r, &hdfs); err != nil {
+			return results, fmt.Errorf("reconcile StatefulSet: %w", err)
+		}
+	}
+
 	for _,r := range res.StatefulSets{
-		_, err := ReconcileStatefulSet(c, hdfs, r)
+		_, err := ReconcileStatefulSet(c, r, &hdfs)
 		if err != nil {
 			return results, fmt.Errorf("reconcile StatefulSet: %w", err)
 		}
 		//if reconciled.Status.ReadyReplicas == hdfs.Spec.Journalnode.Replicas {
-		//	_, _ = ReconcileStatefulSet(c, hdfs, res.Namenode)
+		//	_, _ = ReconcileStatefulSet(c, res.Namenode, &hdfs)
 		//}
 		time.Sleep(time.Second * 90)
-		_, _ = ReconcileStatefulSet(c, hdfs, res.Namenode)
+		_, _ = ReconcileStatefulSet(c, res.Namenode, &hdfs)
 	}
 
-	_ /*reconciled*/, err := ReconcileStatefulSet(c, hdfs, res.Datanode)
+	_ /*reconciled*/, err := ReconcileStatefulSet(c, res.Datanode, &hdfs)
 	if err != nil {
 		return results, fmt.Errorf("reconcile StatefulSet: %w", err)
 	}
 
+	for _,r := range res.DatanodePools{
+		if _, err := ReconcileStatefulSet(c, r, &hdfs); err != nil {
+			return results, fmt.Errorf("reconcile StatefulSet: %w", err)
+		}
+	}
+
 	// update actual with the reconciled ones for next steps to work with up-to-date information
 	//results.ActualStatefulSet = actualStatefulSets.WithStatefulSet(reconciled)
 
 	return results, nil
 }
 
-// ReconcileStatefulSet creates or updates the statefulset kind
-func ReconcileStatefulSet(c client.Client, hdfs hdfsv1.HDFS, expected v1.StatefulSet) (v1.StatefulSet, error) {
+// ReconcileStatefulSet creates or updates the statefulset kind. A changed pod template rolls the
+// pods of the RollingUpdate StatefulSets, the OnDelete ones pick it up as their pods restart.
+func ReconcileStatefulSet(c client.Client, expected v1.StatefulSet, owner client.Object) (v1.StatefulSet, error) {
 	//podTemplateValidator := newPodTemplateValidator(c, hdfs, expected)
 
+	if expected.Annotations == nil {
+		expected.Annotations = map[string]string{}
+	}
+	expected.Annotations[com.SpecHashAnnotation] = com.SpecHash(expected.Spec.Template)
+
 	//create kind instance
 	var reconciled v1.StatefulSet
 	err := ReconcileResource(Params{
 		Client:     c,
-		Owner:      &hdfs,
+		Owner:      owner,
 		Expected:   &expected,
 		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return reconciled.Annotations[com.SpecHashAnnotation] != expected.Annotations[com.SpecHashAnnotation] ||
+				!reflect.DeepEqual(reconciled.Spec.Replicas, expected.Spec.Replicas) ||
+				!containsAll(reconciled.Labels, expected.Labels)
+		},
+		UpdateReconciled: func() {
+			if reconciled.Annotations == nil {
+				reconciled.Annotations = map[string]string{}
+			}
+			if reconciled.Labels == nil {
+				reconciled.Labels = map[string]string{}
+			}
+			for k, v := range expected.Labels {
+				reconciled.Labels[k] = v
+			}
+			reconciled.Annotations[com.SpecHashAnnotation] = expected.Annotations[com.SpecHashAnnotation]
+			reconciled.Spec.Replicas = expected.Spec.Replicas
+			reconciled.Spec.Template = expected.Spec.Template
+		},
 	})
 
 	return reconciled, err
 }
 
+// hasLabels returns true if actual holds every expected label
+func containsAll(actual, expected map[string]string) bool {
+	for k, v := range expected {
+		if actual[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ReconcileJob creates the job kind, jobs are immutable and run once
+func ReconcileJob(c client.Client, expected batchv1.Job, owner client.Object) (batchv1.Job, error) {
+	var reconciled batchv1.Job
+	err := ReconcileResource(Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+	})
+	return reconciled, err
+}
+
 // ReconcileDaemonSet creates or updates the DaemonSet kind
 func ReconcileDaemonSet(c client.Client, hdfs hdfsv1.HDFS, expected v1.DaemonSet) (v1.DaemonSet, error) {
 
@@ -92,12 +169,48 @@ func ReconcileConfigMap(c client.Client, expected corev1.ConfigMap, owner client
 		Owner:      owner,
 		Expected:   &expected,
 		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return !reflect.DeepEqual(expected.Data, reconciled.Data) || !containsAll(reconciled.Labels, expected.Labels)
+		},
+		UpdateReconciled: func() {
+			if reconciled.Labels == nil {
+				reconciled.Labels = map[string]string{}
+			}
+			for k, v := range expected.Labels {
+				reconciled.Labels[k] = v
+			}
+			reconciled.Data = expected.Data
+		},
 	}); err != nil {
 		return corev1.ConfigMap{}, err
 	}
 	return reconciled, nil
 }
 
+// ReconcileSecret creates or updates the secret kind
+func ReconcileSecret(c client.Client, expected corev1.Secret, owner client.Object) (corev1.Secret, error) {
+	var reconciled corev1.Secret
+	if err := ReconcileResource(Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			// an empty map and a nil map are the same for the api server
+			if len(expected.Data) == 0 && len(reconciled.Data) == 0 {
+				return false
+			}
+			return !reflect.DeepEqual(expected.Data, reconciled.Data)
+		},
+		UpdateReconciled: func() {
+			reconciled.Data = expected.Data
+		},
+	}); err != nil {
+		return corev1.Secret{}, err
+	}
+	return reconciled, nil
+}
+
 func ReconcileService(
 	c client.Client,
 	expected *corev1.Service,
@@ -110,6 +223,62 @@ func ReconcileService(
 		Owner:      owner,
 		Expected:   expected,
 		Reconciled: reconciled,
+		NeedsUpdate: func() bool {
+			return expected.Spec.PublishNotReadyAddresses != reconciled.Spec.PublishNotReadyAddresses ||
+				expected.Spec.Type != reconciled.Spec.Type ||
+				!containsAll(reconciled.Annotations, expected.Annotations)
+		},
+		UpdateReconciled: func() {
+			reconciled.Spec.PublishNotReadyAddresses = expected.Spec.PublishNotReadyAddresses
+			reconciled.Spec.Type = expected.Spec.Type
+			// annotations set by others, e.g. the cloud controller, are kept
+			if reconciled.Annotations == nil {
+				reconciled.Annotations = map[string]string{}
+			}
+			for k, v := range expected.Annotations {
+				reconciled.Annotations[k] = v
+			}
+		},
 	})
 	return reconciled, err
 }
+
+// ReconcilePodDisruptionBudget creates or updates the PodDisruptionBudget kind
+func ReconcilePodDisruptionBudget(c client.Client, expected policyv1.PodDisruptionBudget, owner client.Object) (policyv1.PodDisruptionBudget, error) {
+	var reconciled policyv1.PodDisruptionBudget
+	if err := ReconcileResource(Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return !reflect.DeepEqual(expected.Spec.MaxUnavailable, reconciled.Spec.MaxUnavailable)
+		},
+		UpdateReconciled: func() {
+			reconciled.Spec.MaxUnavailable = expected.Spec.MaxUnavailable
+		},
+	}); err != nil {
+		return policyv1.PodDisruptionBudget{}, err
+	}
+	return reconciled, nil
+}
+
+// ReconcileNetworkPolicy creates or updates the NetworkPolicy kind
+func ReconcileNetworkPolicy(c client.Client, expected networkingv1.NetworkPolicy, owner client.Object) (networkingv1.NetworkPolicy, error) {
+	var reconciled networkingv1.NetworkPolicy
+	if err := ReconcileResource(Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return !reflect.DeepEqual(expected.Spec, reconciled.Spec)
+		},
+		UpdateReconciled: func() {
+			reconciled.Spec = expected.Spec
+		},
+	}); err != nil {
+		return networkingv1.NetworkPolicy{}, err
+	}
+	return reconciled, nil
+}
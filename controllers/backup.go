@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"context"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/backup"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileBackup maintains the CronJob backing up the namenode metadata, removing it when
+// backups are disabled, and reports its last runs.
+func (d *DefaultDriver) reconcileBackup(ctx context.Context) *Results {
+	results := &Results{}
+	if d.Hdfs.Spec.Backup == nil {
+		d.ReconcileState.UpdateBackup(nil)
+		stale := batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{
+			Namespace: d.Hdfs.Namespace,
+			Name:      com.GetName(d.Hdfs.Name, backup.BackupJobName),
+		}}
+		if err := d.Client.Delete(ctx, &stale, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return results.WithError(err)
+		}
+		return results
+	}
+
+	expected, err := backup.BuildCronJob(d.Hdfs)
+	if err != nil {
+		return results.WithError(err)
+	}
+	reconciled, err := ReconcileCronJob(d.Client, expected, &d.Hdfs)
+	if err != nil {
+		return results.WithError(err)
+	}
+	d.ReconcileState.UpdateBackup(&v1.BackupStatus{
+		LastScheduleTime:   reconciled.Status.LastScheduleTime,
+		LastSuccessfulTime: reconciled.Status.LastSuccessfulTime,
+	})
+	return results
+}
+
+// ReconcileCronJob creates or updates the CronJob kind
+func ReconcileCronJob(c client.Client, expected batchv1.CronJob, owner client.Object) (batchv1.CronJob, error) {
+	var reconciled batchv1.CronJob
+	err := ReconcileResource(Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return reconciled.Annotations[com.SpecHashAnnotation] != expected.Annotations[com.SpecHashAnnotation]
+		},
+		UpdateReconciled: func() {
+			if reconciled.Annotations == nil {
+				reconciled.Annotations = map[string]string{}
+			}
+			reconciled.Annotations[com.SpecHashAnnotation] = expected.Annotations[com.SpecHashAnnotation]
+			reconciled.Spec = expected.Spec
+		},
+	})
+	return reconciled, err
+}
@@ -0,0 +1,248 @@
+package backup
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"strings"
+)
+
+const (
+	BackupJobName = "backup"
+
+	DefaultSchedule       = "@daily"
+	DefaultKeepLast int32 = 7
+	DefaultS3Image        = "amazon/aws-cli:2.2.30"
+
+	WorkVolumeName      = "backup-work"
+	WorkVolumeMountPath = "/backup-work"
+
+	TargetVolumeName      = "backup-target"
+	TargetVolumeMountPath = "/backup-target"
+
+	// ManifestFileName is a shell sourceable file describing a backup
+	ManifestFileName = "manifest"
+)
+
+var backupBackoffLimit int32 = 2
+
+// Validate checks that exactly one storage is set
+func Validate(storage v1.BackupStorage) error {
+	if (storage.S3 == nil) == (storage.PVC == nil) {
+		return fmt.Errorf("backup storage needs either s3 or pvc")
+	}
+	return nil
+}
+
+// namePattern matches the backups of the cluster, whose names end with their UTC creation time
+func namePattern(hdfs v1.HDFS) string {
+	return "^" + hdfs.Name + "-[0-9]\\{8\\}-[0-9]\\{6\\}$"
+}
+
+func hdfsBin(hdfs v1.HDFS) string {
+	return "/opt/hadoop-" + hdfs.Spec.Version + "/bin/hdfs"
+}
+
+// s3URL returns the s3 url of the storage prefix, without trailing slash
+func s3URL(s3 *v1.S3Storage) string {
+	prefix := strings.Trim(s3.Prefix, "/")
+	if prefix == "" {
+		return "s3://" + s3.Bucket
+	}
+	return "s3://" + s3.Bucket + "/" + prefix
+}
+
+// awsCommand returns the aws command line, pointed to the storage endpoint
+func awsCommand(s3 *v1.S3Storage) string {
+	if s3.Endpoint == "" {
+		return "aws"
+	}
+	return "aws --endpoint-url " + s3.Endpoint
+}
+
+func s3Image(s3 *v1.S3Storage) string {
+	if s3.Image != "" {
+		return s3.Image
+	}
+	return DefaultS3Image
+}
+
+func s3Env(s3 *v1.S3Storage) ([]corev1.EnvVar, []corev1.EnvFromSource) {
+	var env []corev1.EnvVar
+	if s3.Region != "" {
+		env = append(env, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: s3.Region})
+	}
+	var envFrom []corev1.EnvFromSource
+	if s3.CredentialsSecret != nil {
+		envFrom = append(envFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: *s3.CredentialsSecret},
+		})
+	}
+	return env, envFrom
+}
+
+func targetVolume(pvc *v1.PVCStorage) (corev1.Volume, corev1.VolumeMount) {
+	return corev1.Volume{
+			Name: TargetVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.ClaimName},
+			},
+		}, corev1.VolumeMount{
+			Name:      TargetVolumeName,
+			MountPath: TargetVolumeMountPath,
+			SubPath:   pvc.SubPath,
+		}
+}
+
+// fetchScript saves the latest fsimage of the active namenode with a manifest holding the
+// identity of the cluster, which a restore needs to re-create the namenode storage.
+func fetchScript(hdfs v1.HDFS) string {
+	var jmxURLs []string
+	for _, endpoint := range webhdfs.NamenodeEndpoints(hdfs) {
+		jmxURLs = append(jmxURLs, "'"+endpoint+"/jmx?qry=Hadoop:service=NameNode,name=NameNodeInfo'")
+	}
+	return `set -o errexit -o nounset -o pipefail -o xtrace
+_NAME=` + hdfs.Name + `-$(date -u +%Y%m%d-%H%M%S)
+_DIR=` + WorkVolumeMountPath + `/$_NAME
+mkdir -p $_DIR
+` + hdfsBin(hdfs) + ` --config /etc/hadoop dfsadmin -fetchImage $_DIR
+_IMAGE=$(cd $_DIR && ls fsimage_* | grep -v '\.md5$' | head -n 1)
+_INFO=""
+for _URL in ` + strings.Join(jmxURLs, " ") + `; do
+  _INFO=$(curl -sf "$_URL" || wget -qO- "$_URL" || true)
+  if [[ "$_INFO" == *ClusterId* ]]; then break; fi
+done
+_CLUSTER_ID=$(echo "$_INFO" | grep -o '"ClusterId" *: *"[^"]*"' | cut -d'"' -f4)
+_BLOCK_POOL_ID=$(echo "$_INFO" | grep -o '"BlockPoolId" *: *"[^"]*"' | cut -d'"' -f4)
+cat > $_DIR/` + ManifestFileName + ` <<EOM
+NAME=$_NAME
+CLUSTER=` + hdfs.Name + `
+VERSION=` + hdfs.Spec.Version + `
+IMAGE=$_IMAGE
+CLUSTER_ID=$_CLUSTER_ID
+BLOCK_POOL_ID=$_BLOCK_POOL_ID
+EOM
+echo $_NAME > ` + WorkVolumeMountPath + `/name
+`
+}
+
+// uploadScript copies the fetched backup to the storage and deletes the backups beyond keepLast
+func uploadScript(hdfs v1.HDFS, storage v1.BackupStorage, keepLast int32) string {
+	script := `set -o errexit -o nounset -o xtrace
+_NAME=$(cat ` + WorkVolumeMountPath + `/name)
+`
+	prune := fmt.Sprintf(` | grep "%s" | sort | head -n -%d | while read _OLD; do `, namePattern(hdfs), keepLast)
+	if storage.S3 != nil {
+		aws, target := awsCommand(storage.S3), s3URL(storage.S3)
+		return script + aws + ` s3 cp --recursive ` + WorkVolumeMountPath + `/$_NAME ` + target + `/$_NAME/
+` + aws + ` s3 ls ` + target + `/ | awk '$1 == "PRE" { print $2 }' | sed 's#/$##'` + prune +
+			aws + ` s3 rm --recursive ` + target + `/$_OLD/; done
+`
+	}
+	return script + `cp -r ` + WorkVolumeMountPath + `/$_NAME ` + TargetVolumeMountPath + `/$_NAME.tmp
+mv ` + TargetVolumeMountPath + `/$_NAME.tmp ` + TargetVolumeMountPath + `/$_NAME
+ls ` + TargetVolumeMountPath + prune + `rm -rf ` + TargetVolumeMountPath + `/$_OLD; done
+`
+}
+
+// BuildCronJob builds the CronJob backing up the namenode metadata of the cluster
+func BuildCronJob(hdfs v1.HDFS) (batchv1.CronJob, error) {
+	b := hdfs.Spec.Backup
+	if err := Validate(b.BackupStorage); err != nil {
+		return batchv1.CronJob{}, err
+	}
+	schedule := b.Schedule
+	if schedule == "" {
+		schedule = DefaultSchedule
+	}
+	keepLast := DefaultKeepLast
+	if b.KeepLast != nil {
+		keepLast = *b.KeepLast
+	}
+
+	cronJob := types.NamespacedName{Namespace: hdfs.Namespace, Name: com.GetName(hdfs.Name, BackupJobName)}
+	configVolume := com.NewHdfsConfigVolume(hdfs.Name, com.VolumesConfigMapName, com.HdfsConfigMountPath)
+	workVolume := corev1.Volume{Name: WorkVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+	workMount := corev1.VolumeMount{Name: WorkVolumeName, MountPath: WorkVolumeMountPath}
+
+	fetch := corev1.Container{
+		Name:            "fetch-image",
+		Image:           hdfs.Spec.Image,
+		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
+		Env: []corev1.EnvVar{
+			{Name: "HADOOP_CUSTOM_CONF_DIR", Value: com.HdfsConfigMountPath},
+		},
+		Command:      []string{"/entrypoint.sh"},
+		Args:         []string{"/bin/bash", "-c", fetchScript(hdfs)},
+		VolumeMounts: []corev1.VolumeMount{configVolume.VolumeMount(), workMount},
+	}
+	upload := corev1.Container{
+		Name:         "upload",
+		Command:      []string{"/bin/bash", "-c"},
+		Args:         []string{uploadScript(hdfs, b.BackupStorage, keepLast)},
+		VolumeMounts: []corev1.VolumeMount{workMount},
+	}
+	volumes := []corev1.Volume{configVolume.Volume(), workVolume}
+	if b.S3 != nil {
+		upload.Image = s3Image(b.S3)
+		upload.Env, upload.EnvFrom = s3Env(b.S3)
+	} else {
+		upload.Image = hdfs.Spec.Image
+		upload.ImagePullPolicy = corev1.PullPolicy(hdfs.Spec.ImagePullPolicy)
+		volume, mount := targetVolume(b.PVC)
+		volumes = append(volumes, volume)
+		upload.VolumeMounts = append(upload.VolumeMounts, mount)
+	}
+
+	suspend := b.Suspend
+	cj := batchv1.CronJob{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJob",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cronJob.Name,
+			Namespace:       cronJob.Namespace,
+			Labels:          com.NewLabels(cronJob),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:          schedule,
+			Suspend:           &suspend,
+			ConcurrencyPolicy: batchv1.ForbidConcurrent,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backupBackoffLimit,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: com.NewJobPodLabels(com.ExtractNamespacedName(&hdfs), cronJob),
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy:    corev1.RestartPolicyNever,
+							ImagePullSecrets: imagePullSecrets(hdfs.Spec.ImagePullSecrets),
+							InitContainers:   []corev1.Container{fetch},
+							Containers:       []corev1.Container{upload},
+							Volumes:          volumes,
+						},
+					},
+				},
+			},
+		},
+	}
+	com.RestrictPodSpec(hdfs, &cj.Spec.JobTemplate.Spec.Template.Spec)
+	cj.Annotations = map[string]string{com.SpecHashAnnotation: com.SpecHash(cj.Spec)}
+	return cj, nil
+}
+
+func imagePullSecrets(names []string) []corev1.LocalObjectReference {
+	var secrets []corev1.LocalObjectReference
+	for _, name := range names {
+		secrets = append(secrets, corev1.LocalObjectReference{Name: name})
+	}
+	return secrets
+}
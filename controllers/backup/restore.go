@@ -0,0 +1,64 @@
+package backup
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// RestoreDir is where the backup is downloaded in the metadata volume of the first namenode,
+	// the namenode startup script restores it instead of formatting.
+	RestoreDir = "/hadoop/dfs/name/restore"
+
+	metadataDir = "/hadoop/dfs/name/current"
+)
+
+// restoreScript downloads the backup once, on the first namenode and only if it has no metadata yet
+func restoreScript(restore v1.Restore) string {
+	script := `set -o errexit -o nounset -o xtrace
+if [ -d ` + metadataDir + ` ] || [ "$MY_POD" != "$NAMENODE_POD_0" ] || [ -f ` + RestoreDir + `/` + ManifestFileName + ` ]; then
+  exit 0
+fi
+rm -rf ` + RestoreDir + `.tmp
+`
+	if restore.S3 != nil {
+		script += awsCommand(restore.S3) + ` s3 cp --recursive ` + s3URL(restore.S3) + `/` + restore.BackupName + `/ ` + RestoreDir + `.tmp/
+`
+	} else {
+		script += `cp -r ` + TargetVolumeMountPath + `/` + restore.BackupName + ` ` + RestoreDir + `.tmp
+`
+	}
+	return script + `test -f ` + RestoreDir + `.tmp/` + ManifestFileName + `
+mv ` + RestoreDir + `.tmp ` + RestoreDir + `
+`
+}
+
+// BuildRestoreInitContainer builds the init container downloading the backup to restore into
+// the given namenode metadata volume, along with the volumes it needs.
+func BuildRestoreInitContainer(hdfs v1.HDFS, env []corev1.EnvVar, metadata corev1.VolumeMount) (corev1.Container, []corev1.Volume, error) {
+	restore := hdfs.Spec.Restore
+	if err := Validate(restore.BackupStorage); err != nil {
+		return corev1.Container{}, nil, err
+	}
+	container := corev1.Container{
+		Name:         "restore",
+		Command:      []string{"/bin/bash", "-c"},
+		Args:         []string{restoreScript(*restore)},
+		Env:          env,
+		VolumeMounts: []corev1.VolumeMount{metadata},
+	}
+	var volumes []corev1.Volume
+	if restore.S3 != nil {
+		container.Image = s3Image(restore.S3)
+		s3Env, envFrom := s3Env(restore.S3)
+		container.Env = append(append([]corev1.EnvVar{}, env...), s3Env...)
+		container.EnvFrom = envFrom
+	} else {
+		container.Image = hdfs.Spec.Image
+		container.ImagePullPolicy = corev1.PullPolicy(hdfs.Spec.ImagePullPolicy)
+		volume, mount := targetVolume(restore.PVC)
+		volumes = append(volumes, volume)
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+	return container, volumes, nil
+}
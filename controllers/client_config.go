@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	ClientConfigName = "client-config"
+
+	// ClusterNamespaceLabelName is the namespace of the cluster a client config copy comes from
+	ClusterNamespaceLabelName = "dataomnis.io/cluster-namespace"
+)
+
+// reconcileClientConfig renders the client configuration of the cluster and copies it to the
+// namespaces selected by spec.clientConfig, removing the copies of namespaces no longer selected.
+func (d *DefaultDriver) reconcileClientConfig(ctx context.Context) *Results {
+	results := &Results{}
+
+	clientConfig, err := com.BuildClientConfig(d.Hdfs, com.GetName(d.Hdfs.Name, ClientConfigName),
+		com.InternalNamenodeAddresses(d.Hdfs), com.PodNetwork(d.Hdfs))
+	if err != nil {
+		return results.WithError(err)
+	}
+	if _, err := ReconcileConfigMap(d.Client, clientConfig, &d.Hdfs); err != nil {
+		return results.WithError(err)
+	}
+	d.ReconcileState.UpdateClientConfig(clientConfig.Name)
+
+	selected := map[string]bool{}
+	if d.Hdfs.Spec.ClientConfig != nil && d.Hdfs.Spec.ClientConfig.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(d.Hdfs.Spec.ClientConfig.NamespaceSelector)
+		if err != nil {
+			return results.WithError(err)
+		}
+		var namespaces corev1.NamespaceList
+		if err := d.Client.List(ctx, &namespaces, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return results.WithError(err)
+		}
+		for _, ns := range namespaces.Items {
+			if ns.Name == d.Hdfs.Namespace || !ns.DeletionTimestamp.IsZero() {
+				continue
+			}
+			selected[ns.Name] = true
+			// owner references cannot cross namespaces, copies are found by their labels
+			var existing corev1.ConfigMap
+			err := d.Client.Get(ctx, types.NamespacedName{Namespace: ns.Name, Name: clientConfig.Name}, &existing)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return results.WithError(err)
+			}
+			if err == nil && existing.Labels[ClusterNamespaceLabelName] != d.Hdfs.Namespace {
+				results.WithError(fmt.Errorf("client config %s/%s: not copied from namespace %s, not overwriting it",
+					ns.Name, clientConfig.Name, d.Hdfs.Namespace))
+				continue
+			}
+			replica := clientConfig
+			replica.ObjectMeta = metav1.ObjectMeta{
+				Namespace: ns.Name,
+				Name:      clientConfig.Name,
+				Labels:    clientConfigCopyLabels(d.Hdfs),
+			}
+			if _, err := ReconcileConfigMap(d.Client, replica, nil); err != nil {
+				return results.WithError(err)
+			}
+		}
+	}
+
+	var copies corev1.ConfigMapList
+	if err := d.Client.List(ctx, &copies, client.MatchingLabels(clientConfigCopyLabels(d.Hdfs))); err != nil {
+		return results.WithError(err)
+	}
+	for i := range copies.Items {
+		if selected[copies.Items[i].Namespace] {
+			continue
+		}
+		if err := d.Client.Delete(ctx, &copies.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return results.WithError(err)
+		}
+	}
+	return results
+}
+
+func clientConfigCopyLabels(hdfs v1.HDFS) map[string]string {
+	lbls := com.NewLabels(com.ExtractNamespacedName(&hdfs))
+	lbls[ClusterNamespaceLabelName] = hdfs.Namespace
+	return lbls
+}
+
+// hdfsSelectingNamespace returns the requests of the HDFS clusters copying their client
+// configuration to namespaces, the labels of the given one may have started or stopped matching.
+func (r *HDFSReconciler) hdfsSelectingNamespace(obj client.Object) []reconcile.Request {
+	var list v1.HDFSList
+	if err := r.Client.List(context.Background(), &list); err != nil {
+		log.Error(err, "failed to list HDFS for namespace change", "namespace", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, hdfs := range list.Items {
+		if hdfs.Spec.ClientConfig != nil && hdfs.Spec.ClientConfig.NamespaceSelector != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: com.ExtractNamespacedName(&hdfs)})
+		}
+	}
+	return requests
+}
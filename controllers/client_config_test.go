@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileClientConfig(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	hdfs := v1.HDFS{ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "a", UID: "uid"}}
+	hdfs.Spec.ClientConfig = &v1.ClientConfig{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"hdfs-clients": "true"}},
+	}
+	namespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"hdfs-clients": "true"}}}
+	}
+	// the copy of the same named cluster of namespace b
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "shared",
+			Name:      "hdfs-client-config",
+			Labels:    clientConfigCopyLabels(v1.HDFS{ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "b"}}),
+		},
+		Data: map[string]string{"other": "cluster"},
+	}
+	d := &DefaultDriver{
+		Hdfs:           hdfs,
+		Client:         fake.NewClientBuilder().WithScheme(s).WithObjects(&hdfs, namespace("apps"), namespace("shared"), other).Build(),
+		ReconcileState: NewState(hdfs),
+	}
+	ctx := context.Background()
+
+	if _, err := d.reconcileClientConfig(ctx).Aggregate(); err == nil {
+		t.Error("got no error, want the copy of the other cluster refused")
+	}
+
+	var cm corev1.ConfigMap
+	if err := d.Client.Get(ctx, types.NamespacedName{Namespace: "shared", Name: "hdfs-client-config"}, &cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["other"] != "cluster" || cm.Labels[ClusterNamespaceLabelName] != "b" {
+		t.Errorf("got %v %v, want the copy of the other cluster kept", cm.Labels, cm.Data)
+	}
+	if err := d.Client.Get(ctx, types.NamespacedName{Namespace: "apps", Name: "hdfs-client-config"}, &cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Labels[ClusterNamespaceLabelName] != "a" {
+		t.Errorf("got labels %v, want the copy labeled with namespace a", cm.Labels)
+	}
+	if err := d.Client.Get(ctx, types.NamespacedName{Namespace: "a", Name: "hdfs-client-config"}, &cm); err != nil {
+		t.Fatal(err)
+	}
+	if !containsAll(cm.Labels, com.NewLabels(com.ExtractNamespacedName(&hdfs))) {
+		t.Errorf("got labels %v, want the cluster labels", cm.Labels)
+	}
+
+	// deselected namespaces lose the copy of the cluster only
+	d.Hdfs.Spec.ClientConfig = nil
+	if _, err := d.reconcileClientConfig(ctx).Aggregate(); err != nil {
+		t.Fatal(err)
+	}
+	var copies corev1.ConfigMapList
+	if err := d.Client.List(ctx, &copies, client.MatchingLabels{ClusterNamespaceLabelName: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(copies.Items) != 0 {
+		t.Errorf("got %d copies, want none", len(copies.Items))
+	}
+	if err := d.Client.Get(ctx, types.NamespacedName{Namespace: "shared", Name: "hdfs-client-config"}, &cm); err != nil {
+		t.Errorf("got %v, want the copy of the other cluster kept", err)
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	nn "github.com/dataworkbench/hdfs-operator/controllers/namenode"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+)
+
+const FormatBlockedCondition = "NamenodeFormatBlocked"
+
+// reconcileClusterIdentity records the cluster and block pool IDs of the formatted namenodes,
+// which the namenode startup script then refuses to lose, and reports namenodes blocked by it.
+func (d *DefaultDriver) reconcileClusterIdentity(ctx context.Context) *Results {
+	results := &Results{}
+
+	refused, err := d.formatRefusal(ctx)
+	if err != nil {
+		return results.WithError(err)
+	}
+	if refused != "" {
+		d.ReconcileState.SetCondition(FormatBlockedCondition, metav1.ConditionTrue, "FormatRefused",
+			refused+"; set spec.namenode.allowReformat to proceed")
+		return results.WithResult(defaultRequeue)
+	}
+	d.ReconcileState.SetCondition(FormatBlockedCondition, metav1.ConditionFalse, "MetadataFound", "")
+
+	if d.Hdfs.Status.ClusterID != "" && !d.Hdfs.Spec.Namenode.AllowReformat {
+		return results
+	}
+	ready, err := namenodesReady(ctx, d)
+	if err != nil {
+		return results.WithError(err)
+	}
+	if !ready {
+		return results.WithResult(defaultRequeue)
+	}
+	info, err := webhdfs.ForCluster(d.Hdfs).GetNameNodeInfo(ctx)
+	if err != nil {
+		// the namenode may still be starting
+		log.Info("Cluster identity not available yet", "namespace", d.Hdfs.Namespace, "name", d.Hdfs.Name, "error", err.Error())
+		return results.WithResult(defaultRequeue)
+	}
+	d.ReconcileState.UpdateClusterIdentity(info.ClusterId, info.BlockPoolId)
+	return results
+}
+
+// formatRefusal returns the reason a namenode last exited with, naming its pod, if it refused to format.
+func (d *DefaultDriver) formatRefusal(ctx context.Context) (string, error) {
+	var pods corev1.PodList
+	ssetName := com.GetName(d.Hdfs.Name, d.Hdfs.Spec.Namenode.Name)
+	if err := d.Client.List(ctx, &pods,
+		client.InNamespace(d.Hdfs.Namespace),
+		client.MatchingLabels(com.NewStatefulSetLabels(com.ExtractNamespacedName(&d.Hdfs), ssetName)),
+	); err != nil {
+		return "", err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != ssetName || status.Ready {
+				continue
+			}
+			for _, state := range []corev1.ContainerState{status.State, status.LastTerminationState} {
+				if state.Terminated != nil && strings.HasPrefix(state.Terminated.Message, nn.FormatRefusedMessage) {
+					return fmt.Sprintf("pod %s: %s", pod.Name, strings.TrimSpace(state.Terminated.Message)), nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
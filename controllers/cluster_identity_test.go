@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	nn "github.com/dataworkbench/hdfs-operator/controllers/namenode"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func namenodePod(hdfs v1.HDFS, name, message string) *corev1.Pod {
+	ssetName := com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: hdfs.Namespace,
+			Labels:    com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), ssetName),
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: ssetName, Ready: message == ""}}},
+	}
+	if message != "" {
+		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{Message: message}
+	}
+	return pod
+}
+
+func TestFormatRefusal(t *testing.T) {
+	hdfs := v1.HDFS{ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "ns"}}
+	hdfs.Spec.Namenode.Name = "namenode"
+	refusal := nn.FormatRefusedMessage + ": no metadata found\n"
+
+	for _, tt := range []struct {
+		name string
+		pods []client.Object
+		want string
+	}{
+		{"no pods", nil, ""},
+		{"namenodes running", []client.Object{
+			namenodePod(hdfs, "hdfs-namenode-0", ""),
+			namenodePod(hdfs, "hdfs-namenode-1", ""),
+		}, ""},
+		{"second namenode refused", []client.Object{
+			namenodePod(hdfs, "hdfs-namenode-0", ""),
+			namenodePod(hdfs, "hdfs-namenode-1", refusal),
+		}, "pod hdfs-namenode-1: " + nn.FormatRefusedMessage + ": no metadata found"},
+		{"other failure", []client.Object{
+			namenodePod(hdfs, "hdfs-namenode-0", "out of memory"),
+		}, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DefaultDriver{
+				Hdfs:   hdfs,
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tt.pods...).Build(),
+			}
+			got, err := d.formatRefusal(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
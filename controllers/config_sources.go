@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// siteConfigs returns the inline properties of the spec keyed by site file name.
+func siteConfigs(hdfs v1.HDFS) map[string][]v1.ClusterConfig {
+	return map[string][]v1.ClusterConfig{
+		com.CoreSiteFileName:   hdfs.Spec.CoreSite,
+		com.HdfsSiteFileName:   hdfs.Spec.HdfsSite,
+		com.MapredSiteFileName: hdfs.Spec.Yarn.MapredSite,
+		com.YarnSiteFileName:   hdfs.Spec.Yarn.YarnSite,
+	}
+}
+
+// configSourceFetcher reads the ConfigMaps and Secrets referenced by an HDFS spec,
+// fetching each object once per reconciliation.
+type configSourceFetcher struct {
+	ctx        context.Context
+	client     client.Client
+	namespace  string
+	configMaps map[string]*corev1.ConfigMap
+	secrets    map[string]*corev1.Secret
+}
+
+func newConfigSourceFetcher(ctx context.Context, c client.Client, namespace string) *configSourceFetcher {
+	return &configSourceFetcher{
+		ctx:        ctx,
+		client:     c,
+		namespace:  namespace,
+		configMaps: map[string]*corev1.ConfigMap{},
+		secrets:    map[string]*corev1.Secret{},
+	}
+}
+
+// configMap returns the named ConfigMap, or nil if it does not exist.
+func (f *configSourceFetcher) configMap(name string) (*corev1.ConfigMap, error) {
+	if cm, ok := f.configMaps[name]; ok {
+		return cm, nil
+	}
+	var cm corev1.ConfigMap
+	err := f.client.Get(f.ctx, types.NamespacedName{Namespace: f.namespace, Name: name}, &cm)
+	if apierrors.IsNotFound(err) {
+		f.configMaps[name] = nil
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	f.configMaps[name] = &cm
+	return &cm, nil
+}
+
+// secret returns the named Secret, or nil if it does not exist.
+func (f *configSourceFetcher) secret(name string) (*corev1.Secret, error) {
+	if s, ok := f.secrets[name]; ok {
+		return s, nil
+	}
+	var s corev1.Secret
+	err := f.client.Get(f.ctx, types.NamespacedName{Namespace: f.namespace, Name: name}, &s)
+	if apierrors.IsNotFound(err) {
+		f.secrets[name] = nil
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	f.secrets[name] = &s
+	return &s, nil
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+// ResolveExternalConfig resolves the property values and site file overlays the HDFS spec
+// takes from ConfigMaps and Secrets. Values coming from Secrets are kept apart so that
+// they only end up in the secret config.
+func ResolveExternalConfig(ctx context.Context, c client.Client, hdfs v1.HDFS) (com.ExternalConfig, error) {
+	ext := com.NewExternalConfig()
+	fetcher := newConfigSourceFetcher(ctx, c, hdfs.Namespace)
+
+	for _, file := range com.SiteFileNames {
+		for _, cfg := range siteConfigs(hdfs)[file] {
+			if cfg.ValueFrom == nil {
+				continue
+			}
+			switch src := cfg.ValueFrom; {
+			case src.ConfigMapKeyRef != nil && src.SecretKeyRef != nil:
+				return ext, fmt.Errorf("property %s: only one of configMapKeyRef and secretKeyRef may be set", cfg.Property)
+			case src.ConfigMapKeyRef != nil:
+				ref := src.ConfigMapKeyRef
+				cm, err := fetcher.configMap(ref.Name)
+				if err != nil {
+					return ext, err
+				}
+				value, ok := "", false
+				if cm != nil {
+					value, ok = cm.Data[ref.Key]
+				}
+				if !ok {
+					if isOptional(ref.Optional) {
+						continue
+					}
+					return ext, fmt.Errorf("property %s: key %s not found in ConfigMap %s/%s", cfg.Property, ref.Key, hdfs.Namespace, ref.Name)
+				}
+				ext.Plain[file] = append(ext.Plain[file], com.Property{Name: cfg.Property, Value: value})
+			case src.SecretKeyRef != nil:
+				ref := src.SecretKeyRef
+				s, err := fetcher.secret(ref.Name)
+				if err != nil {
+					return ext, err
+				}
+				var value []byte
+				ok := false
+				if s != nil {
+					value, ok = s.Data[ref.Key]
+				}
+				if !ok {
+					if isOptional(ref.Optional) {
+						continue
+					}
+					return ext, fmt.Errorf("property %s: key %s not found in Secret %s/%s", cfg.Property, ref.Key, hdfs.Namespace, ref.Name)
+				}
+				ext.Secret[file] = append(ext.Secret[file], com.Property{Name: cfg.Property, Value: string(value)})
+			default:
+				return ext, fmt.Errorf("property %s: valueFrom needs a configMapKeyRef or a secretKeyRef", cfg.Property)
+			}
+		}
+	}
+
+	for _, src := range hdfs.Spec.ConfigFrom {
+		switch {
+		case src.ConfigMapRef != nil && src.SecretRef != nil:
+			return ext, fmt.Errorf("configFrom: only one of configMapRef and secretRef may be set")
+		case src.ConfigMapRef != nil:
+			cm, err := fetcher.configMap(src.ConfigMapRef.Name)
+			if err != nil {
+				return ext, err
+			}
+			if cm == nil {
+				return ext, fmt.Errorf("configFrom: ConfigMap %s/%s not found", hdfs.Namespace, src.ConfigMapRef.Name)
+			}
+			for _, file := range com.SiteFileNames {
+				data, ok := cm.Data[file]
+				if !ok {
+					continue
+				}
+				props, err := com.ParseConfiguration([]byte(data))
+				if err != nil {
+					return ext, fmt.Errorf("configFrom: parse %s of ConfigMap %s: %w", file, src.ConfigMapRef.Name, err)
+				}
+				ext.Plain[file] = append(ext.Plain[file], props...)
+			}
+		case src.SecretRef != nil:
+			s, err := fetcher.secret(src.SecretRef.Name)
+			if err != nil {
+				return ext, err
+			}
+			if s == nil {
+				return ext, fmt.Errorf("configFrom: Secret %s/%s not found", hdfs.Namespace, src.SecretRef.Name)
+			}
+			for _, file := range com.SiteFileNames {
+				data, ok := s.Data[file]
+				if !ok {
+					continue
+				}
+				props, err := com.ParseConfiguration(data)
+				if err != nil {
+					return ext, fmt.Errorf("configFrom: parse %s of Secret %s: %w", file, src.SecretRef.Name, err)
+				}
+				ext.Secret[file] = append(ext.Secret[file], props...)
+			}
+		default:
+			return ext, fmt.Errorf("configFrom: needs a configMapRef or a secretRef")
+		}
+	}
+	return ext, nil
+}
+
+// referencedConfigSources returns the names of the ConfigMaps and Secrets the HDFS spec reads configuration from.
+func referencedConfigSources(hdfs v1.HDFS) (configMaps map[string]bool, secrets map[string]bool) {
+	configMaps, secrets = map[string]bool{}, map[string]bool{}
+	for _, cfgs := range siteConfigs(hdfs) {
+		for _, cfg := range cfgs {
+			if cfg.ValueFrom == nil {
+				continue
+			}
+			if cfg.ValueFrom.ConfigMapKeyRef != nil {
+				configMaps[cfg.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+			if cfg.ValueFrom.SecretKeyRef != nil {
+				secrets[cfg.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+	}
+	for _, src := range hdfs.Spec.ConfigFrom {
+		if src.ConfigMapRef != nil {
+			configMaps[src.ConfigMapRef.Name] = true
+		}
+		if src.SecretRef != nil {
+			secrets[src.SecretRef.Name] = true
+		}
+	}
+	return configMaps, secrets
+}
+
+// hdfsReferencing returns the requests of the HDFS clusters reading configuration from the given object,
+// so that their config is rendered again when it changes.
+func (r *HDFSReconciler) hdfsReferencing(obj client.Object) []reconcile.Request {
+	var list v1.HDFSList
+	if err := r.Client.List(context.Background(), &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list HDFS referencing config source", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+	_, isSecret := obj.(*corev1.Secret)
+	var requests []reconcile.Request
+	for _, hdfs := range list.Items {
+		configMaps, secrets := referencedConfigSources(hdfs)
+		if (isSecret && secrets[obj.GetName()]) || (!isSecret && configMaps[obj.GetName()]) {
+			requests = append(requests, reconcile.Request{NamespacedName: com.ExtractNamespacedName(&hdfs)})
+		}
+	}
+	return requests
+}
@@ -3,24 +3,34 @@ package datanode
 import (
 	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/external"
 	corev1 "k8s.io/api/core/v1"
 )
 
 
-// BuildPodTemplateSpec builds a new PodTemplateSpec for DataNode.
-func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTemplateSpec, error) {
-	volumes, volumeMounts := buildVolumes(hdfs.Name,hdfs.Spec.Datanode)
+// BuildPodTemplateSpec builds a new PodTemplateSpec for the DataNodes of a pool.
+func BuildPodTemplateSpec(hdfs v1.HDFS, pool v1.DatanodePool, labels map[string]string) (corev1.PodTemplateSpec, error) {
+	volumes, volumeMounts := buildVolumes(hdfs.Name, pool.Datanode)
+	if hdfs.Spec.ExternalAccess != nil {
+		addressesVolume := external.BuildVolume(hdfs)
+		volumes = append(volumes, addressesVolume.Volume())
+		volumeMounts = append(volumeMounts, addressesVolume.VolumeMount())
+	}
 
-	container := buildContainer(hdfs.Spec.Datanode.Name, volumeMounts, hdfs)
+	container := buildContainer(pool.Name, volumeMounts, hdfs, pool)
 
 	builder := &com.PodTemplateBuilder{}
 	builder.WithContainers(container).
 		WithSpecVolumes(volumes...).
 		WithImagePullSecrets(hdfs.Spec.ImagePullSecrets...).
 		WithRestartPolicy(corev1.RestartPolicyAlways).
-		WithHostNetwork(defaultOptional).
-		WithHostPID(defaultOptional).
-		WithDNSPolicy(corev1.DNSClusterFirstWithHostNet).
+		WithNetwork(hdfs).
+		WithHostPID(!com.PodNetwork(hdfs)).
+		WithPodSecurity(hdfs).
+		WithNodeSelector(pool.NodeSelector).
+		WithTolerations(pool.Tolerations...).
+		WithDefaultAntiAffinity(labels).
+		WithDefaultTopologySpread(labels).
 		WithTemplateMetadata(labels)
 
 	return builder.PodTemplate, nil
@@ -28,57 +38,123 @@ func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTem
 
 func buildVolumes(name string, nodeSpec v1.Datanode) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
 
-	configVolume := com.NewConfigMapVolume(com.GetName(name, com.CommonConfigName), com.VolumesConfigMapName, com.HdfsConfigMountPath)
-
-	scriptsVolume := com.NewConfigMapVolumeWithMode(com.GetName(name, DatanodeScripts), DNScriptsVolumeName, DNScriptsVolumeMountPath, 0744)
+	configVolume := com.NewHdfsConfigVolume(name, com.VolumesConfigMapName, com.HdfsConfigMountPath)
 
 	// append container volumeMounts from PVCs
-	persistentVolumes := make([]corev1.VolumeMount, 0, len(nodeSpec.Datadirs))
+	persistentVolumes := make([]corev1.VolumeMount, 0, len(nodeSpec.Datadirs)+len(nodeSpec.Volumes))
 	for _, dir := range nodeSpec.Datadirs {
+		if len(nodeSpec.Volumes) > 0 {
+			// Datadirs are replaced by Volumes
+			break
+		}
 		persistentVolumes = append(persistentVolumes, corev1.VolumeMount{
 			Name:      DNDataVolumeName,
 			MountPath: DNDataVolumeMountPath+dir,
 			SubPath: dir,
 		})
 	}
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	for _, v := range nodeSpec.Volumes {
+		persistentVolumes = append(persistentVolumes, corev1.VolumeMount{
+			Name:      volumeName(v),
+			MountPath: DNDataVolumeMountPath + v.Name,
+		})
+		if v.HostPath != "" {
+			volumes = append(volumes, corev1.Volume{
+				Name: volumeName(v),
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: v.HostPath, Type: &hostPathType},
+				},
+			})
+		}
+	}
 
 	//SSetSpec.Template.Spec.Volume
 	volumes = append(volumes,
-		scriptsVolume.Volume(),
 		configVolume.Volume(),
 	)
 	//SSetSpec.Template.Spec.containers.volumeMounts
 	volumeMounts = append(persistentVolumes,
-		scriptsVolume.VolumeMount(),
 		configVolume.VolumeMount(),
 	)
 
 	return volumes, volumeMounts
 }
 
-func buildContainer(name string, volumeMounts []corev1.VolumeMount, hdfs v1.HDFS) corev1.Container {
-
-	probe := &corev1.Probe{
-		Handler: corev1.Handler{
-			Exec: &corev1.ExecAction{
-				Command: []string{"/dn-scripts/check-status.sh"},
-			},
-		},
-		InitialDelaySeconds: 60,
-		PeriodSeconds:       30,
+func buildContainer(name string, volumeMounts []corev1.VolumeMount, hdfs v1.HDFS, pool v1.DatanodePool) corev1.Container {
+	command := []string{"/entrypoint.sh"}
+	if hdfs.Spec.ExternalAccess != nil {
+		command = []string{"/bin/bash", "-c", external.DatanodeScript, "datanode"}
 	}
-
-	return corev1.Container{
+	return defaultProbes(hdfs.Spec.Version).WithProbes(corev1.Container{
 		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
 		Image:           hdfs.Spec.Image,
 		Name:            name,
-		Env:             envVars(),
-		Command:         []string{"/entrypoint.sh"},
-		Args:            []string{"/opt/hadoop-"+hdfs.Spec.Version+"/bin/hdfs", "--config", "/etc/hadoop", "datanode"},
+		Env:             append(envVars(), com.HeapEnvVars(hdfs.Spec.Version, com.DatanodeRole, pool.Resources, pool.HeapPercent)...),
+		Command:         command,
+		Args:            args(hdfs, pool),
 		VolumeMounts:    volumeMounts,
-		LivenessProbe:   probe,
-		ReadinessProbe:  probe,
-		SecurityContext: &corev1.SecurityContext{Privileged: &defaultOptional},
+		SecurityContext: securityContext(hdfs),
+		Resources:       pool.Resources,
+	}, pool.Probes)
+}
+
+// defaultProbes report the datanode ready once it registered with the namenodes and got the
+// cluster ID, checked on the datanode HTTP port.
+func defaultProbes(version string) com.RoleProbes {
+	return com.RoleProbes{
+		Startup: com.TCPProbe(com.DatanodeRpcPort(version), 10, 30),
+		Readiness: com.HTTPCheckProbe(com.DatanodeRpcPort(version), com.JMXQuery("Hadoop:service=DataNode,name=DataNodeInfo"), 30, 3,
+			`"ClusterId" *: *"[^"]+"`),
+		Liveness: com.TCPProbe(com.DatanodeRpcPort(version), 10, 3),
+	}
+}
+
+// securityContext keeps the datanodes on the host network privileged, on the pod network the
+// restricted context is set with the pod one.
+func securityContext(hdfs v1.HDFS) *corev1.SecurityContext {
+	if com.PodNetwork(hdfs) {
+		return nil
+	}
+	return &corev1.SecurityContext{Privileged: &defaultOptional}
+}
+
+// args runs the datanode, pools other than the default datanodes pass their own data dirs
+// as the shared hdfs-site.xml holds the ones of the default datanodes.
+func args(hdfs v1.HDFS, pool v1.DatanodePool) []string {
+	args := []string{"/opt/hadoop-"+hdfs.Spec.Version+"/bin/hdfs", "--config", "/etc/hadoop", "datanode"}
+	if pool.Name != hdfs.Spec.Datanode.Name {
+		args = append(args, "-D", "dfs.datanode.data.dir="+com.DatanodeDataDirs(pool.Datanode))
+	}
+	if com.PodNetwork(hdfs) {
+		// registered under the name of the pod in the headless Service of the pool
+		ssetName := com.GetName(hdfs.Name, pool.Name)
+		args = append(args, "-D", "dfs.datanode.hostname=$(POD_NAME)."+ssetName+"."+hdfs.Namespace+".svc.cluster.local")
+	}
+	return args
+}
+
+// DataPort returns the default data transfer port of the datanodes
+func DataPort(hdfs v1.HDFS) int32 {
+	if com.IsHadoop3(hdfs.Spec.Version) {
+		return 9866
+	}
+	return 50010
+}
+
+// IPCPort returns the default IPC port of the datanodes, used for block recovery
+func IPCPort(hdfs v1.HDFS) int32 {
+	if com.IsHadoop3(hdfs.Spec.Version) {
+		return 9867
+	}
+	return 50020
+}
+
+// GetDefaultServicePorts returns the HTTP and data transfer ports of the datanodes
+func GetDefaultServicePorts(hdfs v1.HDFS) []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{Name: "http", Port: int32(com.DatanodeRpcPort(hdfs.Spec.Version))},
+		{Name: "data", Port: DataPort(hdfs)},
 	}
 }
 
@@ -86,5 +162,11 @@ func envVars() []corev1.EnvVar {
 	return []corev1.EnvVar{
 		{Name: "HADOOP_CUSTOM_CONF_DIR", Value: "/etc/hadoop-custom-conf"},
 		{Name: "MULTIHOMED_NETWORK", Value: "0"},
+		{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+		}},
+		{Name: "HOST_IP", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.hostIP"},
+		}},
 	}
 }
@@ -1,36 +1,80 @@
 package datanode
 
 import (
+	"fmt"
 	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	com "github.com/dataworkbench/hdfs-operator/common"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
 )
 
 
 const (
 	DNDataVolumeName      = "hdfs-data"
-	DNDataVolumeMountPath = "/hadoop/dfs/data/"
-
-	DNScriptsVolumeName      = "dn-scripts"
-	DNScriptsVolumeMountPath = "/dn-scripts"
+	DNDataVolumeMountPath = com.DatanodeDataPath
 )
 
 var defaultOptional = true
 
 func BuildStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
-	statefulSetName := com.GetName(hdfs.Name, hdfs.Spec.Datanode.Name)
+	return BuildPoolStatefulSet(hdfs, DefaultPool(hdfs))
+}
+
+// DefaultPool returns the datanodes of spec.datanode as a pool
+func DefaultPool(hdfs v1.HDFS) v1.DatanodePool {
+	return v1.DatanodePool{Datanode: hdfs.Spec.Datanode}
+}
+
+// Pools returns every datanode pool of the cluster, the default one first
+func Pools(hdfs v1.HDFS) []v1.DatanodePool {
+	return append([]v1.DatanodePool{DefaultPool(hdfs)}, hdfs.Spec.DatanodePools...)
+}
+
+// BuildPoolStatefulSets builds the StatefulSets of the pools of spec.datanodePools
+func BuildPoolStatefulSets(hdfs v1.HDFS) ([]appsv1.StatefulSet, error) {
+	names := map[string]bool{hdfs.Spec.Datanode.Name: true}
+	var ssets []appsv1.StatefulSet
+	for _, pool := range hdfs.Spec.DatanodePools {
+		if names[pool.Name] {
+			return nil, fmt.Errorf("duplicate datanode pool %s", pool.Name)
+		}
+		names[pool.Name] = true
+		sset, err := BuildPoolStatefulSet(hdfs, pool)
+		if err != nil {
+			return nil, err
+		}
+		ssets = append(ssets, sset)
+	}
+	return ssets, nil
+}
+
+// BuildPoolStatefulSet builds the StatefulSet of a datanode pool
+func BuildPoolStatefulSet(hdfs v1.HDFS, pool v1.DatanodePool) (appsv1.StatefulSet, error) {
+	statefulSetName := com.GetName(hdfs.Name, pool.Name)
 	// ssetSelector is used to match the StatefulSet pods
 	ssetSelector := com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), statefulSetName)
 
-	volumeClaimTemplates := com.AppendPVCs(DNDataVolumeName, hdfs.Spec.Datanode.StorageClass,hdfs.Spec.Datanode.Capacity)
+	if err := validateVolumes(hdfs, pool.Datanode); err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+	volumeClaimTemplates := buildVolumeClaimTemplates(pool.Datanode)
 
 	// build pod template,associate PVCs to pod container
-	podTemplate, err := BuildPodTemplateSpec(hdfs, ssetSelector)
+	podTemplate, err := BuildPodTemplateSpec(hdfs, pool, ssetSelector)
 	if err != nil {
 		return appsv1.StatefulSet{}, err
 	}
 
+	// the pool label finds the StatefulSets of the pools removed from the spec
+	labels := map[string]string{com.DatanodePoolLabelName: pool.Name}
+	for k, v := range ssetSelector {
+		labels[k] = v
+	}
+
 	sset := appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "StatefulSet",
@@ -39,7 +83,7 @@ func BuildStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: hdfs.Namespace,
 			Name:      statefulSetName,
-			Labels:    ssetSelector,
+			Labels:    labels,
 			OwnerReferences: com.GetOwnerReference(hdfs),
 		},
 		Spec: appsv1.StatefulSetSpec{
@@ -47,14 +91,76 @@ func BuildStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
 				Type: appsv1.OnDeleteStatefulSetStrategyType,
 			},
 			RevisionHistoryLimit: nil,
-			//ServiceName:          statefulSetName,
+			ServiceName:          statefulSetName,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: ssetSelector,
 			},
-			Replicas:             &hdfs.Spec.Datanode.Replicas,
+			Replicas:             &pool.Replicas,
 			VolumeClaimTemplates: volumeClaimTemplates,
 			Template:             podTemplate,
 		},
 	}
 	return sset, nil
 }
+
+// volumeName returns the name of the claim or host path volume of a datanode volume
+func volumeName(v v1.DatanodeVolume) string {
+	return DNDataVolumeName + "-" + v.Name
+}
+
+// buildVolumeClaimTemplates returns one claim per volume not backed by a host directory,
+// or the single claim holding all Datadirs.
+func buildVolumeClaimTemplates(dn v1.Datanode) (pvcs []corev1.PersistentVolumeClaim) {
+	if len(dn.Volumes) == 0 {
+		return com.AppendPVCs(DNDataVolumeName, dn.StorageClass, dn.Capacity)
+	}
+	for _, v := range dn.Volumes {
+		if v.HostPath != "" {
+			continue
+		}
+		sc, capacity := v.StorageClass, v.Capacity
+		if sc == "" {
+			sc = dn.StorageClass
+		}
+		if capacity == "" {
+			capacity = dn.Capacity
+		}
+		pvcs = append(pvcs, com.AppendPVCs(volumeName(v), sc, capacity)...)
+	}
+	return pvcs
+}
+
+func validateVolumes(hdfs v1.HDFS, dn v1.Datanode) error {
+	// the default capacity sizes the single claim without volumes
+	if dn.Capacity != "" || len(dn.Volumes) == 0 {
+		if _, err := resource.ParseQuantity(dn.Capacity); err != nil {
+			return fmt.Errorf("datanode %s: capacity: %w", dn.Name, err)
+		}
+	}
+	names := map[string]bool{}
+	for _, v := range dn.Volumes {
+		if v.Name == "" {
+			return fmt.Errorf("datanode volume without name")
+		}
+		// the name ends up in a claim name and in a data directory
+		if errs := validation.IsDNS1123Label(v.Name); len(errs) > 0 {
+			return fmt.Errorf("datanode volume %s: %s", v.Name, strings.Join(errs, ", "))
+		}
+		if v.HostPath != "" && com.PodNetwork(hdfs) {
+			return fmt.Errorf("datanode volume %s: host paths are not allowed on the pod network", v.Name)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("duplicate datanode volume %s", v.Name)
+		}
+		names[v.Name] = true
+		if v.HostPath == "" && v.Capacity == "" && dn.Capacity == "" {
+			return fmt.Errorf("datanode volume %s: no capacity", v.Name)
+		}
+		if v.Capacity != "" {
+			if _, err := resource.ParseQuantity(v.Capacity); err != nil {
+				return fmt.Errorf("datanode volume %s: capacity: %w", v.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package controllers
+
+import (
+	"context"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	dn "github.com/dataworkbench/hdfs-operator/controllers/datanode"
+	"github.com/dataworkbench/hdfs-operator/controllers/decommission"
+	"github.com/dataworkbench/hdfs-operator/controllers/external"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// listPoolPods returns the pods of the given datanode pools.
+func listPoolPods(ctx context.Context, c client.Client, hdfs v1.HDFS, pools []v1.DatanodePool) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+	for _, pool := range pools {
+		var list corev1.PodList
+		ssetName := com.GetName(hdfs.Name, pool.Name)
+		if err := c.List(ctx, &list,
+			client.InNamespace(hdfs.Namespace),
+			client.MatchingLabels(com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), ssetName)),
+		); err != nil {
+			return nil, err
+		}
+		pods = append(pods, list.Items...)
+	}
+	return pods, nil
+}
+
+// isDatanodePod returns true if the StatefulSet of the pod belongs to a datanode pool of the cluster.
+func isDatanodePod(hdfs v1.HDFS, ssetName string) bool {
+	for _, pool := range dn.Pools(hdfs) {
+		if ssetName == com.GetName(hdfs.Name, pool.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// poolDecommission is the decommission progress of the datanodes of a pool StatefulSet
+type poolDecommission struct {
+	// excluded are the pods of the StatefulSet listed in the exclude file
+	excluded []corev1.Pod
+	// done is true once the namenode reports every excluded datanode as decommissioned
+	done bool
+}
+
+// removedPools returns the StatefulSets of the datanode pools no longer in the spec
+func removedPools(ctx context.Context, c client.Client, hdfs v1.HDFS) ([]appsv1.StatefulSet, error) {
+	var list appsv1.StatefulSetList
+	if err := c.List(ctx, &list,
+		client.InNamespace(hdfs.Namespace),
+		client.MatchingLabels(com.NewLabels(com.ExtractNamespacedName(&hdfs))),
+		client.HasLabels{com.DatanodePoolLabelName},
+	); err != nil {
+		return nil, err
+	}
+	var removed []appsv1.StatefulSet
+	for _, sset := range list.Items {
+		if !isDatanodePod(hdfs, sset.Name) {
+			removed = append(removed, sset)
+		}
+	}
+	return removed, nil
+}
+
+// observeDecommissions returns the decommission progress of each datanode StatefulSet. The pods of
+// the decommissioned pools, of the pools removed from the spec and the ones above the replicas of
+// their pool are excluded, and done once the active namenode reports them decommissioned.
+func (d *DefaultDriver) observeDecommissions(ctx context.Context) (map[string]poolDecommission, error) {
+	kept := map[string]int32{}
+	for _, pool := range dn.Pools(d.Hdfs) {
+		replicas := pool.Replicas
+		if pool.Decommission {
+			replicas = 0
+		}
+		kept[com.GetName(d.Hdfs.Name, pool.Name)] = replicas
+	}
+	removed, err := removedPools(ctx, d.Client, d.Hdfs)
+	if err != nil {
+		return nil, err
+	}
+	for _, sset := range removed {
+		kept[sset.Name] = 0
+	}
+
+	decommissions := map[string]poolDecommission{}
+	excluding := false
+	for ssetName, replicas := range kept {
+		pods, err := statefulSetPodsAbove(ctx, d.Client, d.Hdfs, ssetName, replicas)
+		if err != nil {
+			return nil, err
+		}
+		decommissions[ssetName] = poolDecommission{excluded: pods, done: len(pods) == 0}
+		excluding = excluding || len(pods) > 0
+	}
+	if !excluding {
+		return decommissions, nil
+	}
+
+	advertised, err := d.advertisedHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states, err := webhdfs.ForCluster(d.Hdfs).GetDatanodeAdminStates(ctx)
+	if err != nil {
+		// the datanodes stay excluded, and the pools scaled, until the namenode answers
+		log.Info("Cannot get the datanode admin states", "namespace", d.Hdfs.Namespace, "name", d.Hdfs.Name, "error", err.Error())
+		return decommissions, nil
+	}
+	for ssetName, decommission := range decommissions {
+		decommission.done = allDecommissioned(decommission.excluded, states, advertised)
+		decommissions[ssetName] = decommission
+	}
+	return decommissions, nil
+}
+
+// advertisedHosts returns the external host each datanode pod registers with, by pod name
+func (d *DefaultDriver) advertisedHosts(ctx context.Context) (map[string]string, error) {
+	hosts := map[string]string{}
+	if d.Hdfs.Spec.ExternalAccess == nil {
+		return hosts, nil
+	}
+	var addresses corev1.ConfigMap
+	err := d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: com.GetName(d.Hdfs.Name, external.AddressesConfigName)}, &addresses)
+	if apierrors.IsNotFound(err) {
+		return hosts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for podName, address := range addresses.Data {
+		if i := strings.LastIndex(address, ":"); i >= 0 {
+			address = address[:i]
+		}
+		hosts[podName] = address
+	}
+	return hosts, nil
+}
+
+// allDecommissioned returns true if the namenode reports each pod as decommissioned, under any of
+// the addresses the datanode may register with. Pods it reports otherwise or not at all are not,
+// unless they never started and hold no blocks.
+func allDecommissioned(pods []corev1.Pod, states map[string]string, advertised map[string]string) bool {
+	for _, pod := range pods {
+		if len(pod.Status.ContainerStatuses) == 0 {
+			continue
+		}
+		service := pod.Name + "." + pod.Labels[com.StatefulSetLabel] + "." + pod.Namespace + ".svc"
+		decommissioned := false
+		for _, host := range []string{advertised[pod.Name], service, service + ".cluster.local", pod.Name,
+			pod.Status.PodIP, pod.Spec.NodeName} {
+			state, ok := states[host]
+			if !ok || host == "" {
+				continue
+			}
+			if state != webhdfs.AdminStateDecommissioned {
+				return false
+			}
+			decommissioned = true
+		}
+		if !decommissioned {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileDecommission lists the excluded datanodes in the exclude file of the namenodes, and has
+// the namenodes read it again whenever its content changes. The StatefulSets of the removed pools
+// are deleted once their datanodes are decommissioned.
+func (d *DefaultDriver) reconcileDecommission(ctx context.Context) *Results {
+	results := &Results{}
+	decommissions, err := d.observeDecommissions(ctx)
+	if err != nil {
+		return results.WithError(err)
+	}
+	d.decommissions = decommissions
+
+	var pods []corev1.Pod
+	for _, decommission := range decommissions {
+		pods = append(pods, decommission.excluded...)
+		if !decommission.done {
+			// the namenode reports no event, poll it until the datanodes are decommissioned
+			results.WithResult(defaultRequeue)
+		}
+	}
+	refreshing, err := d.reconcileExcludeFile(ctx, decommission.BuildConfigMap(d.Hdfs, pods))
+	if err != nil {
+		return results.WithError(err)
+	}
+	if refreshing {
+		results.WithResult(defaultRequeue)
+	}
+	return results.WithError(d.deleteRemovedPools(ctx))
+}
+
+// reconcileExcludeFile updates the exclude file, and runs a job having the namenodes read it until
+// one completes for its current content, returning true meanwhile. The config map records the
+// content last read with its refreshed annotation, namenodes created with it read its first
+// content on startup. Finished jobs are deleted, for a content to be read again when it comes back.
+func (d *DefaultDriver) reconcileExcludeFile(ctx context.Context, expected corev1.ConfigMap) (bool, error) {
+	exclude := expected.Data[com.ExcludeFileName]
+	jobName := decommission.JobName(d.Hdfs, exclude)
+
+	var actual corev1.ConfigMap
+	err := d.Client.Get(ctx, types.NamespacedName{Namespace: expected.Namespace, Name: expected.Name}, &actual)
+	if apierrors.IsNotFound(err) {
+		expected.Annotations = map[string]string{decommission.RefreshedAnnotation: jobName}
+		_, err := ReconcileConfigMap(d.Client, expected, &d.Hdfs)
+		return false, err
+	}
+	if err != nil {
+		return false, err
+	}
+	reconciled, err := ReconcileConfigMap(d.Client, expected, &d.Hdfs)
+	if err != nil {
+		return false, err
+	}
+	if err := d.deleteStaleRefreshJobs(ctx, jobName); err != nil {
+		return false, err
+	}
+	if reconciled.Annotations[decommission.RefreshedAnnotation] == jobName {
+		return false, nil
+	}
+
+	var job batchv1.Job
+	err = d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: jobName}, &job)
+	switch {
+	case apierrors.IsNotFound(err):
+		// namenodes started before the change still run with the previous exclude list
+		_, err := ReconcileJob(d.Client, decommission.BuildRefreshNodesJob(d.Hdfs, exclude), &d.Hdfs)
+		return true, err
+	case err != nil:
+		return false, err
+	case jobHasCondition(job, batchv1.JobFailed):
+		log.Info("Refreshing the datanodes failed, retrying", "namespace", job.Namespace, "name", job.Name)
+		return true, deleteJob(ctx, d.Client, job)
+	case !jobHasCondition(job, batchv1.JobComplete):
+		return true, nil
+	}
+
+	if reconciled.Annotations == nil {
+		reconciled.Annotations = map[string]string{}
+	}
+	reconciled.Annotations[decommission.RefreshedAnnotation] = jobName
+	if err := d.Client.Update(ctx, &reconciled); err != nil {
+		return false, err
+	}
+	return false, deleteJob(ctx, d.Client, job)
+}
+
+// deleteStaleRefreshJobs deletes the refresh jobs of the cluster run for a previous exclude content
+func (d *DefaultDriver) deleteStaleRefreshJobs(ctx context.Context, current string) error {
+	var jobs batchv1.JobList
+	if err := d.Client.List(ctx, &jobs, client.InNamespace(d.Hdfs.Namespace)); err != nil {
+		return err
+	}
+	prefix := com.GetName(d.Hdfs.Name, decommission.RefreshNodesJobName) + "-"
+	for _, job := range jobs.Items {
+		if job.Name == current || !strings.HasPrefix(job.Name, prefix) || !metav1.IsControlledBy(&job, &d.Hdfs) {
+			continue
+		}
+		if err := deleteJob(ctx, d.Client, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRemovedPools deletes the StatefulSet, headless Service and PodDisruptionBudget of the pools
+// removed from the spec, once their datanodes are decommissioned.
+func (d *DefaultDriver) deleteRemovedPools(ctx context.Context) error {
+	removed, err := removedPools(ctx, d.Client, d.Hdfs)
+	if err != nil {
+		return err
+	}
+	for _, sset := range removed {
+		if !d.decommissions[sset.Name].done {
+			continue
+		}
+		log.Info("Deleting removed datanode pool", "namespace", sset.Namespace, "name", sset.Name)
+		meta := metav1.ObjectMeta{Namespace: sset.Namespace, Name: sset.Name}
+		for _, obj := range []client.Object{&appsv1.StatefulSet{ObjectMeta: meta}, &corev1.Service{ObjectMeta: meta},
+			&policyv1.PodDisruptionBudget{ObjectMeta: meta}} {
+			if err := d.Client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// keepDatanodeReplicas keeps the datanodes a pool scales down from until the namenode reports them
+// decommissioned, for their blocks to be copied to the remaining datanodes first.
+func (d *DefaultDriver) keepDatanodeReplicas(ctx context.Context, res *HdfsResources) error {
+	expected := []*appsv1.StatefulSet{&res.Datanode}
+	for i := range res.DatanodePools {
+		expected = append(expected, &res.DatanodePools[i])
+	}
+	for _, sset := range expected {
+		var actual appsv1.StatefulSet
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: sset.Namespace, Name: sset.Name}, &actual)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if actual.Spec.Replicas == nil || sset.Spec.Replicas == nil || *actual.Spec.Replicas <= *sset.Spec.Replicas {
+			continue
+		}
+		if decommission, ok := d.decommissions[sset.Name]; ok && decommission.done {
+			continue
+		}
+		sset.Spec.Replicas = actual.Spec.Replicas
+	}
+	return nil
+}
+
+// updateDatanodePoolStatus reports the replicas and the decommission progress of every pool of
+// spec.datanodePools.
+func (d *DefaultDriver) updateDatanodePoolStatus(ctx context.Context) *Results {
+	results := &Results{}
+	var statuses []v1.DatanodePoolStatus
+	for _, pool := range d.Hdfs.Spec.DatanodePools {
+		ssetName := com.GetName(d.Hdfs.Name, pool.Name)
+		var sset appsv1.StatefulSet
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: ssetName}, &sset)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return results.WithError(err)
+		}
+		decommission, observed := d.decommissions[ssetName]
+		statuses = append(statuses, v1.DatanodePoolStatus{
+			Name:            pool.Name,
+			Replicas:        sset.Status.Replicas,
+			ReadyReplicas:   sset.Status.ReadyReplicas,
+			Decommissioning: observed && !decommission.done,
+			Decommissioned:  observed && pool.Decommission && decommission.done,
+		})
+	}
+	d.ReconcileState.UpdateDatanodePools(statuses)
+	return results
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/decommission"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func datanodePod(name string, started bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ns",
+			Labels:    map[string]string{com.StatefulSetLabel: "hdfs-pool"},
+		},
+		Spec:   corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{PodIP: "10.0.0.1"},
+	}
+	if started {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "datanode"}}
+	}
+	return pod
+}
+
+func TestAllDecommissioned(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		pods       []corev1.Pod
+		states     map[string]string
+		advertised map[string]string
+		done       bool
+	}{
+		{"no pods", nil, nil, nil, true},
+		{"pod never started", []corev1.Pod{datanodePod("hdfs-pool-0", false)}, nil, nil, true},
+		{"pod unknown to the namenode", []corev1.Pod{datanodePod("hdfs-pool-0", true)},
+			map[string]string{"other": webhdfs.AdminStateDecommissioned}, nil, false},
+		{"pod decommissioning", []corev1.Pod{datanodePod("hdfs-pool-0", true)},
+			map[string]string{"10.0.0.1": webhdfs.AdminStateDecommissioning}, nil, false},
+		{"pod in service", []corev1.Pod{datanodePod("hdfs-pool-0", true)},
+			map[string]string{"hdfs-pool-0": webhdfs.AdminStateInService}, nil, false},
+		{"pod decommissioned by ip", []corev1.Pod{datanodePod("hdfs-pool-0", true)},
+			map[string]string{"10.0.0.1": webhdfs.AdminStateDecommissioned}, nil, true},
+		{"pod decommissioned by service name", []corev1.Pod{datanodePod("hdfs-pool-0", true)},
+			map[string]string{"hdfs-pool-0.hdfs-pool.ns.svc": webhdfs.AdminStateDecommissioned}, nil, true},
+		{"pod decommissioned by advertised host", []corev1.Pod{datanodePod("hdfs-pool-0", true)},
+			map[string]string{"dn0.example.com": webhdfs.AdminStateDecommissioned},
+			map[string]string{"hdfs-pool-0": "dn0.example.com"}, true},
+		{"one pod left", []corev1.Pod{datanodePod("hdfs-pool-0", true), datanodePod("hdfs-pool-1", true)},
+			map[string]string{"hdfs-pool-0": webhdfs.AdminStateDecommissioned}, nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if done := allDecommissioned(tt.pods, tt.states, tt.advertised); done != tt.done {
+				t.Errorf("got %v, want %v", done, tt.done)
+			}
+		})
+	}
+}
+
+func replicas(n int32) *int32 {
+	return &n
+}
+
+func statefulSet(name string, n int32) appsv1.StatefulSet {
+	return appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: replicas(n)},
+	}
+}
+
+func TestKeepDatanodeReplicas(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		decommissions map[string]poolDecommission
+		replicas      int32
+	}{
+		{"decommission not observed", nil, 3},
+		{"decommission in progress", map[string]poolDecommission{"hdfs-pool": {done: false}}, 3},
+		{"decommission done", map[string]poolDecommission{"hdfs-pool": {done: true}}, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := statefulSet("hdfs-pool", 3)
+			d := &DefaultDriver{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&actual).Build(),
+				decommissions: tt.decommissions,
+			}
+			res := &HdfsResources{
+				Datanode:      statefulSet("hdfs-datanode", 2),
+				DatanodePools: []appsv1.StatefulSet{statefulSet("hdfs-pool", 1)},
+			}
+			if err := d.keepDatanodeReplicas(context.Background(), res); err != nil {
+				t.Fatal(err)
+			}
+			if got := *res.DatanodePools[0].Spec.Replicas; got != tt.replicas {
+				t.Errorf("got %d replicas, want %d", got, tt.replicas)
+			}
+			if got := *res.Datanode.Spec.Replicas; got != 2 {
+				t.Errorf("got %d replicas for a missing statefulset, want 2", got)
+			}
+		})
+	}
+}
+
+func TestReconcileExcludeFile(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	hdfs := v1.HDFS{ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "ns", UID: "uid"}}
+	d := &DefaultDriver{Hdfs: hdfs, Client: fake.NewClientBuilder().WithScheme(s).WithObjects(&hdfs).Build()}
+	ctx := context.Background()
+
+	excludeFile := func(exclude string) corev1.ConfigMap {
+		return corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "hdfs-dn-exclude", Namespace: "ns"},
+			Data:       map[string]string{com.ExcludeFileName: exclude},
+		}
+	}
+	getJob := func(exclude string) (batchv1.Job, error) {
+		var job batchv1.Job
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: "ns", Name: decommission.JobName(hdfs, exclude)}, &job)
+		return job, err
+	}
+	setCondition := func(job batchv1.Job, condition batchv1.JobConditionType) {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue}}
+		if err := d.Client.Status().Update(ctx, &job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconcile := func(exclude string, want bool) {
+		t.Helper()
+		refreshing, err := d.reconcileExcludeFile(ctx, excludeFile(exclude))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refreshing != want {
+			t.Fatalf("got refreshing %v, want %v", refreshing, want)
+		}
+	}
+
+	// the namenodes read the file created with them
+	reconcile("", false)
+	if _, err := getJob(""); !apierrors.IsNotFound(err) {
+		t.Fatalf("got %v, want no job", err)
+	}
+
+	// a new content is refreshed until a job completes
+	reconcile("dn-0", true)
+	job, err := getJob("dn-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconcile("dn-0", true)
+	setCondition(job, batchv1.JobFailed)
+	reconcile("dn-0", true)
+	if _, err := getJob("dn-0"); !apierrors.IsNotFound(err) {
+		t.Fatalf("got %v, want the failed job deleted", err)
+	}
+	reconcile("dn-0", true)
+	job, err = getJob("dn-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setCondition(job, batchv1.JobComplete)
+	reconcile("dn-0", false)
+	if _, err := getJob("dn-0"); !apierrors.IsNotFound(err) {
+		t.Fatalf("got %v, want the completed job deleted", err)
+	}
+	reconcile("dn-0", false)
+
+	// a previous content is refreshed again, and the jobs of other contents are deleted
+	reconcile("dn-0\ndn-1", true)
+	reconcile("", true)
+	if _, err := getJob("dn-0\ndn-1"); !apierrors.IsNotFound(err) {
+		t.Fatalf("got %v, want the stale job deleted", err)
+	}
+	if _, err := getJob(""); err != nil {
+		t.Fatal(err)
+	}
+
+	var jobs batchv1.JobList
+	if err := d.Client.List(ctx, &jobs, client.InNamespace("ns")); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Items) != 1 {
+		t.Errorf("got %d jobs, want 1", len(jobs.Items))
+	}
+}
@@ -0,0 +1,75 @@
+package decommission
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
+)
+
+const (
+	ExcludeConfigName = "dn-exclude"
+	ExcludeVolumeName = "dn-exclude"
+)
+
+// Hosts returns the addresses the namenodes may know the given datanode pods by,
+// the pod ip and the node name being the same as the host ones with host networking.
+func Hosts(pods []corev1.Pod) []string {
+	unique := map[string]bool{}
+	for _, pod := range pods {
+		for _, host := range []string{pod.Status.PodIP, pod.Spec.NodeName} {
+			if host != "" {
+				unique[host] = true
+			}
+		}
+	}
+	hosts := make([]string, 0, len(unique))
+	for host := range unique {
+		hosts = append(hosts, host)
+	}
+	// sort for a stable config map content
+	sort.Strings(hosts)
+	return hosts
+}
+
+// BuildExclude renders the dfs.hosts.exclude file listing the datanodes of the decommissioned pools
+func BuildExclude(pods []corev1.Pod) string {
+	hosts := Hosts(pods)
+	if len(hosts) == 0 {
+		return ""
+	}
+	return strings.Join(hosts, "\n") + "\n"
+}
+
+// BuildConfigMap builds the config map holding the exclude file read by the namenodes
+func BuildConfigMap(hdfs v1.HDFS, pods []corev1.Pod) corev1.ConfigMap {
+
+	configmap := types.NamespacedName{Namespace: hdfs.Namespace, Name: com.GetName(hdfs.Name, ExcludeConfigName)}
+
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            configmap.Name,
+			Namespace:       configmap.Namespace,
+			Labels:          com.NewLabels(configmap),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Data: map[string]string{
+			com.ExcludeFileName: BuildExclude(pods),
+		},
+	}
+}
+
+// BuildVolume returns the volume exposing the exclude config map to the namenodes
+func BuildVolume(hdfs v1.HDFS) com.ConfigMapVolume {
+	return com.NewConfigMapVolumeWithMode(com.GetName(hdfs.Name, ExcludeConfigName),
+		ExcludeVolumeName,
+		com.ExcludeConfigMountPath,
+		0444)
+}
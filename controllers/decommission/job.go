@@ -0,0 +1,95 @@
+package decommission
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	RefreshNodesJobName = "refresh-nodes"
+
+	// RefreshedAnnotation is set on the exclude config map with the name of the job for the
+	// content the namenodes last read
+	RefreshedAnnotation = "dataomnis.io/refreshed-exclude"
+
+	// configPropagationSeconds leaves the kubelets time to update the exclude file mounted by the namenodes
+	configPropagationSeconds = "90"
+)
+
+var (
+	refreshNodesBackoffLimit int32 = 3
+	refreshNodesJobTTL       int32 = 3600
+)
+
+// JobName returns the name of the job applying the given exclude file. The job is deleted once
+// applied, for the same content to be applied again later on.
+func JobName(hdfs v1.HDFS, exclude string) string {
+	sum := sha256.Sum256([]byte(exclude))
+	return com.GetName(hdfs.Name, RefreshNodesJobName) + "-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// BuildRefreshNodesJob builds the job making the namenodes read the exclude file again,
+// which starts or stops the decommissioning of the listed datanodes.
+func BuildRefreshNodesJob(hdfs v1.HDFS, exclude string) batchv1.Job {
+
+	job := types.NamespacedName{Namespace: hdfs.Namespace, Name: JobName(hdfs, exclude)}
+
+	configVolume := com.NewHdfsConfigVolume(hdfs.Name, com.VolumesConfigMapName, com.HdfsConfigMountPath)
+	hdfsCmd := "/opt/hadoop-" + hdfs.Spec.Version + "/bin/hdfs --config /etc/hadoop"
+
+	refresh := batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            job.Name,
+			Namespace:       job.Namespace,
+			Labels:          com.NewLabels(job),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &refreshNodesBackoffLimit,
+			TTLSecondsAfterFinished: &refreshNodesJobTTL,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: com.NewJobPodLabels(com.ExtractNamespacedName(&hdfs), job),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyOnFailure,
+					ImagePullSecrets: imagePullSecrets(hdfs.Spec.ImagePullSecrets),
+					Volumes:          []corev1.Volume{configVolume.Volume()},
+					Containers: []corev1.Container{
+						{
+							Name:            RefreshNodesJobName,
+							Image:           hdfs.Spec.Image,
+							ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
+							Env: []corev1.EnvVar{
+								{Name: "HADOOP_CUSTOM_CONF_DIR", Value: com.HdfsConfigMountPath},
+							},
+							Command:      []string{"/entrypoint.sh"},
+							Args:         []string{"/bin/sh", "-c", "sleep " + configPropagationSeconds + " && " + hdfsCmd + " dfsadmin -refreshNodes"},
+							VolumeMounts: []corev1.VolumeMount{configVolume.VolumeMount()},
+						},
+					},
+				},
+			},
+		},
+	}
+	com.RestrictPodSpec(hdfs, &refresh.Spec.Template.Spec)
+	return refresh
+}
+
+func imagePullSecrets(names []string) []corev1.LocalObjectReference {
+	var secrets []corev1.LocalObjectReference
+	for _, name := range names {
+		secrets = append(secrets, corev1.LocalObjectReference{Name: name})
+	}
+	return secrets
+}
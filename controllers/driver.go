@@ -14,8 +14,11 @@ type DefaultDriver struct {
 	Client   client.Client
 	Recorder record.EventRecorder
 
-	//// State holds the accumulated state during the reconcile loop
-	//ReconcileState *reconcile.State
+	// State holds the accumulated state during the reconcile loop
+	ReconcileState *State
+
+	// decommissions is the decommission progress of the datanode StatefulSets, observed first
+	decommissions map[string]poolDecommission
 	//// Observers that observe es clusters state.
 	//Observers *observer.Manager
 }
@@ -26,9 +29,27 @@ func (d *DefaultDriver) Reconcile(ctx context.Context) *Results {
 	//ObservedStateResolver Monitor the status of components
 	//d.Observers.ObservedStateResolver(){}
 
+	// the namenodes mount the rack topology, reconcile it first
+	results = results.WithResults(d.reconcileRackTopology(ctx))
+	// as well as the exclude file of the decommissioned datanodes
+	results = results.WithResults(d.reconcileDecommission(ctx))
+	// and the one of the autoscaled NodeManagers, read by the ResourceManagers on startup
+	results = results.WithResults(d.reconcileNodeManagerScaling(ctx))
+
 	// reconcile StatefulSets and nodes configuration
-	_ = d.reconcileNodeSpecs(ctx)
-	//results = results.WithResults(res)
+	res := d.reconcileNodeSpecs(ctx)
+	results = results.WithResults(res)
+	results = results.WithResults(d.updateDatanodePoolStatus(ctx))
+	results = results.WithResults(d.reconcileClusterIdentity(ctx))
+	results = results.WithResults(d.updateZkfcStatus(ctx))
+	results = results.WithResults(d.reconcileClientConfig(ctx))
+	results = results.WithResults(d.reconcileResourceManagers(ctx))
+	results = results.WithResults(d.reconcileExternalAccess(ctx))
+	results = results.WithResults(d.reconcileUI(ctx))
+	results = results.WithResults(d.reconcileNetworkPolicies(ctx))
+	// directories are managed through the namenodes, once they run
+	results = results.WithResults(d.reconcilePaths(ctx))
+	results = results.WithResults(d.reconcileBackup(ctx))
 	//d.ReconcileState.UpdateHdfsState(*resourcesState, observedState)
 
 	return results
@@ -37,16 +58,32 @@ func (d *DefaultDriver) Reconcile(ctx context.Context) *Results {
 func (d *DefaultDriver) reconcileNodeSpecs(ctx context.Context) *Results {
 	results := &Results{}
 	////step1  Parsing customer kind HDFS
-	expectedResources, err := BuildExpectedResources(d.Hdfs)
+	ext, err := ResolveExternalConfig(ctx, d.Client, d.Hdfs)
+	if err != nil {
+		return results.WithError(err)
+	}
+	expectedResources, err := BuildExpectedResources(d.Hdfs, ext)
 	if err != nil {
 		return results.WithError(err)
 	}
+	if err := d.keepNodeManagerReplicas(ctx, expectedResources.StatefulSets); err != nil {
+		return results.WithError(err)
+	}
+	if err := d.keepDatanodeReplicas(ctx, &expectedResources); err != nil {
+		return results.WithError(err)
+	}
+	// resize the claims of grown volumes before the StatefulSets are reconciled,
+	// StatefulSets deleted to update their claim templates are re-created below
+	results.WithResults(d.reconcileVolumeExpansion(ctx, expectedResources.AllStatefulSets()))
+	results.WithResults(d.reconcileServiceNames(ctx, expectedResources.AllStatefulSets()))
 	//step2 apply expected k8s kind
 	upscaleResults, err := HandleUpscaleAndSpecChanges(d.Client, d.Hdfs, expectedResources)
+	if err != nil {
+		return results.WithError(err)
+	}
 
 	if upscaleResults.Requeue {
-		//return results.WithResult(defaultRequeue)
-		return results
+		return results.WithResult(defaultRequeue)
 	}
 	return results
 }
\ No newline at end of file
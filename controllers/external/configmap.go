@@ -0,0 +1,55 @@
+package external
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	AddressesConfigName = "external-addresses"
+	AddressesVolumeName = "external-addresses"
+	AddressesMountPath  = "/etc/hadoop-external"
+
+	ClientConfigName = "external-client-config"
+)
+
+// DatanodeScript waits for the external address of the datanode pod to be published, then runs
+// the datanode given as arguments with it. An empty host stands for the node of the pod.
+const DatanodeScript = `_ADDRESS_FILE=` + AddressesMountPath + `/$POD_NAME
+until [ -s $_ADDRESS_FILE ]; do echo "waiting for the external address of $POD_NAME"; sleep 5; done
+_ADDRESS=$(cat $_ADDRESS_FILE)
+_HOST=${_ADDRESS%:*}
+_PORT=${_ADDRESS##*:}
+exec /entrypoint.sh "$@" -D dfs.datanode.hostname=${_HOST:-$HOST_IP} -D dfs.datanode.address=0.0.0.0:$_PORT`
+
+// BuildAddressesConfigMap builds the config map of the host:port the datanodes register with,
+// by pod name.
+func BuildAddressesConfigMap(hdfs v1.HDFS, addresses map[string]string) corev1.ConfigMap {
+
+	configmap := types.NamespacedName{Namespace: hdfs.Namespace, Name: com.GetName(hdfs.Name, AddressesConfigName)}
+
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            configmap.Name,
+			Namespace:       configmap.Namespace,
+			Labels:          com.NewLabels(configmap),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Data: addresses,
+	}
+}
+
+// BuildVolume returns the volume exposing the external addresses to the datanodes
+func BuildVolume(hdfs v1.HDFS) com.ConfigMapVolume {
+	return com.NewConfigMapVolumeWithMode(com.GetName(hdfs.Name, AddressesConfigName),
+		AddressesVolumeName,
+		AddressesMountPath,
+		0444)
+}
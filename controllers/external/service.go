@@ -0,0 +1,76 @@
+package external
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// ServiceLabelName marks the Services exposing a single pod outside of Kubernetes
+	ServiceLabelName = "dataomnis.io/external-access"
+
+	// podNameLabel is set by the StatefulSet controller on each of its pods
+	podNameLabel = "statefulset.kubernetes.io/pod-name"
+)
+
+// ServiceName returns the name of the Service exposing the pod
+func ServiceName(podName string) string {
+	return podName + "-external"
+}
+
+// PodNames returns the names of the pods of a StatefulSet
+func PodNames(ssetName string, replicas int32) []string {
+	names := make([]string, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		names = append(names, fmt.Sprintf("%s-%d", ssetName, i))
+	}
+	return names
+}
+
+// BuildService builds the Service exposing a single pod on the given ports
+func BuildService(hdfs v1.HDFS, podName string, ports []corev1.ServicePort) corev1.Service {
+	labels := com.NewLabels(com.ExtractNamespacedName(&hdfs))
+	labels[ServiceLabelName] = "true"
+	return corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            ServiceName(podName),
+			Labels:          labels,
+			Annotations:     hdfs.Spec.ExternalAccess.Annotations,
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     hdfs.Spec.ExternalAccess.Type,
+			Selector: map[string]string{podNameLabel: podName},
+			Ports:    ports,
+			// keep the client address of the connections
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		},
+	}
+}
+
+// ServicePort returns a port forwarded to the same port of the pod
+func ServicePort(name string, port int32) corev1.ServicePort {
+	return corev1.ServicePort{Name: name, Port: port, TargetPort: intstr.FromInt(int(port))}
+}
+
+// IngressHost returns the address of the load balancer of the Service, if provisioned
+func IngressHost(svc corev1.Service) string {
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	dn "github.com/dataworkbench/hdfs-operator/controllers/datanode"
+	"github.com/dataworkbench/hdfs-operator/controllers/external"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// reconcileExternalAccess exposes each namenode and datanode pod with its own Service, publishes
+// the addresses the datanodes register with, and the client configuration of the namenodes.
+// Addresses of load balancers not provisioned yet are retried later.
+func (d *DefaultDriver) reconcileExternalAccess(ctx context.Context) *Results {
+	results := &Results{}
+	if d.Hdfs.Spec.ExternalAccess == nil {
+		d.ReconcileState.UpdateExternalClientConfig("")
+		return results.WithError(d.deleteExternalAccess(ctx))
+	}
+	access := d.Hdfs.Spec.ExternalAccess
+	pending := false
+	exposed := map[string]bool{}
+
+	// namenodes are reached on the ports they listen on
+	var namenodes []com.NamenodeAddress
+	nnSsetName := com.GetName(d.Hdfs.Name, d.Hdfs.Spec.Namenode.Name)
+	for _, podName := range external.PodNames(nnSsetName, d.Hdfs.Spec.Namenode.Replicas) {
+		exposed[podName] = true
+		svc, err := ReconcileService(d.Client, ptrService(external.BuildService(d.Hdfs, podName, []corev1.ServicePort{
+			external.ServicePort("fs", int32(com.NamenodeRpcPort(d.Hdfs.Spec.Version))),
+			external.ServicePort("http", int32(com.NamenodeHttpPort(d.Hdfs.Spec.Version))),
+		})), &d.Hdfs)
+		if err != nil {
+			return results.WithError(err)
+		}
+		host, err := d.externalHost(ctx, *svc, podName)
+		if err != nil {
+			return results.WithError(err)
+		}
+		if host == "" {
+			pending = true
+			continue
+		}
+		namenodes = append(namenodes, com.NamenodeAddress{
+			RPC:  fmt.Sprintf("%s:%d", host, externalPort(*svc, "fs")),
+			HTTP: fmt.Sprintf("%s:%d", host, externalPort(*svc, "http")),
+		})
+	}
+
+	// datanodes register with their external address, with NodePort they listen on their node port
+	addresses := map[string]string{}
+	dataPort := dn.DataPort(d.Hdfs)
+	for _, pool := range dn.Pools(d.Hdfs) {
+		for _, podName := range external.PodNames(com.GetName(d.Hdfs.Name, pool.Name), pool.Replicas) {
+			exposed[podName] = true
+			svc, err := ReconcileService(d.Client, ptrService(external.BuildService(d.Hdfs, podName, []corev1.ServicePort{
+				external.ServicePort("data", dataPort),
+			})), &d.Hdfs)
+			if err != nil {
+				return results.WithError(err)
+			}
+			if access.Type == corev1.ServiceTypeNodePort {
+				if err := d.forwardToNodePort(ctx, svc); err != nil {
+					return results.WithError(err)
+				}
+				// the datanode falls back to the address of its node
+				addresses[podName] = fmt.Sprintf("%s:%d", access.Addresses[podName], svc.Spec.Ports[0].NodePort)
+				continue
+			}
+			host, err := d.externalHost(ctx, *svc, podName)
+			if err != nil {
+				return results.WithError(err)
+			}
+			if host == "" {
+				pending = true
+				continue
+			}
+			addresses[podName] = fmt.Sprintf("%s:%d", host, dataPort)
+		}
+	}
+	if _, err := ReconcileConfigMap(d.Client, external.BuildAddressesConfigMap(d.Hdfs, addresses), &d.Hdfs); err != nil {
+		return results.WithError(err)
+	}
+	if err := d.deleteStaleExternalServices(ctx, exposed); err != nil {
+		return results.WithError(err)
+	}
+
+	if len(namenodes) == int(d.Hdfs.Spec.Namenode.Replicas) {
+		clientConfig, err := com.BuildClientConfig(d.Hdfs, com.GetName(d.Hdfs.Name, external.ClientConfigName), namenodes, true)
+		if err != nil {
+			return results.WithError(err)
+		}
+		if _, err := ReconcileConfigMap(d.Client, clientConfig, &d.Hdfs); err != nil {
+			return results.WithError(err)
+		}
+		d.ReconcileState.UpdateExternalClientConfig(clientConfig.Name)
+	}
+	if pending {
+		results.WithResult(defaultRequeue)
+	}
+	return results
+}
+
+// externalHost returns the host clients reach the pod exposed by the Service at, empty if not known yet
+func (d *DefaultDriver) externalHost(ctx context.Context, svc corev1.Service, podName string) (string, error) {
+	if host := d.Hdfs.Spec.ExternalAccess.Addresses[podName]; host != "" {
+		return host, nil
+	}
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		return external.IngressHost(svc), nil
+	}
+	var pod corev1.Pod
+	err := d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: podName}, &pod)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	return pod.Status.HostIP, err
+}
+
+// externalPort returns the port of the Service clients connect to
+func externalPort(svc corev1.Service, name string) int32 {
+	for _, port := range svc.Spec.Ports {
+		if port.Name != name {
+			continue
+		}
+		if svc.Spec.Type == corev1.ServiceTypeNodePort {
+			return port.NodePort
+		}
+		return port.Port
+	}
+	return 0
+}
+
+// forwardToNodePort has the Service of a datanode forward its node port to the same port of the
+// pod, the datanode advertising the port it listens on.
+func (d *DefaultDriver) forwardToNodePort(ctx context.Context, svc *corev1.Service) error {
+	port := &svc.Spec.Ports[0]
+	if port.NodePort == 0 || port.TargetPort == intstr.FromInt(int(port.NodePort)) {
+		return nil
+	}
+	port.TargetPort = intstr.FromInt(int(port.NodePort))
+	return d.Client.Update(ctx, svc)
+}
+
+// deleteStaleExternalServices removes the Services of the pods above the replicas of their
+// StatefulSet, or of a removed pool, once the pods are gone. Datanodes still decommissioning keep
+// their Service.
+func (d *DefaultDriver) deleteStaleExternalServices(ctx context.Context, exposed map[string]bool) error {
+	var services corev1.ServiceList
+	if err := d.Client.List(ctx, &services,
+		client.InNamespace(d.Hdfs.Namespace),
+		client.MatchingLabels(com.NewLabels(com.ExtractNamespacedName(&d.Hdfs))),
+		client.HasLabels{external.ServiceLabelName},
+	); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		podName := strings.TrimSuffix(services.Items[i].Name, external.ServiceName(""))
+		if exposed[podName] {
+			continue
+		}
+		var pod corev1.Pod
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: podName}, &pod)
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err := d.Client.Delete(ctx, &services.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExternalAccess removes the per-pod Services and the published addresses
+func (d *DefaultDriver) deleteExternalAccess(ctx context.Context) error {
+	var services corev1.ServiceList
+	if err := d.Client.List(ctx, &services,
+		client.InNamespace(d.Hdfs.Namespace),
+		client.MatchingLabels(com.NewLabels(com.ExtractNamespacedName(&d.Hdfs))),
+		client.HasLabels{external.ServiceLabelName},
+	); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		if err := d.Client.Delete(ctx, &services.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, name := range []string{external.AddressesConfigName, external.ClientConfigName} {
+		stale := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Namespace: d.Hdfs.Namespace,
+			Name:      com.GetName(d.Hdfs.Name, name),
+		}}
+		if err := d.Client.Delete(ctx, &stale); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func ptrService(svc corev1.Service) *corev1.Service {
+	return &svc
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// labelHAStates sets the HA state label of each pod to the state at the same index, leaving the
+// pods of unknown state as is.
+func (d *DefaultDriver) labelHAStates(ctx context.Context, podNames []string, states []string) error {
+	for i, state := range states {
+		if state == "" || i >= len(podNames) {
+			continue
+		}
+		var pod corev1.Pod
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: podNames[i]}, &pod)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if pod.Labels[com.HAStateLabelName] == state {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[com.HAStateLabelName] = state
+		if err := d.Client.Patch(ctx, &pod, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -19,12 +19,17 @@ package controllers
 import (
 	"context"
 	"github.com/dataworkbench/hdfs-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // HDFSReconciler reconciles a HDFS object
@@ -37,6 +42,20 @@ type HDFSReconciler struct {
 //+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfs/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods;nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=patch
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -60,7 +79,23 @@ func (r *HDFSReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	state := NewState(hdfs)
 	results := r.internalReconcile(ctx, hdfs, state)
 
-	return results.WithError(err).Aggregate()
+	if err := r.updateStatus(ctx, state); err != nil {
+		if errors.IsConflict(err) {
+			// the status will be computed again from the latest HDFS
+			return results.WithResult(defaultRequeue).Aggregate()
+		}
+		results.WithError(err)
+	}
+	return results.Aggregate()
+}
+
+// updateStatus writes the status accumulated during the reconciliation, if it changed.
+func (r *HDFSReconciler) updateStatus(ctx context.Context, state *State) error {
+	cluster := state.Apply()
+	if cluster == nil {
+		return nil
+	}
+	return r.Client.Status().Update(ctx, cluster)
 }
 
 func (r *HDFSReconciler) fetchHdfsKind(ctx context.Context, request reconcile.Request, hdfs *v1.HDFS) (bool, error) {
@@ -85,6 +120,20 @@ func (r *HDFSReconciler) fetchHdfsKind(ctx context.Context, request reconcile.Re
 func (r *HDFSReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1.HDFS{}).
+		// render the config again when a referenced ConfigMap or Secret changes
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.hdfsReferencing)).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.hdfsReferencing)).
+		// keep the rack topology and the decommissioned hosts in sync with the datanode placement
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.hdfsOfPod),
+			builder.WithPredicates(datanodeLocationChanged)).
+		// report the health of the failover controllers as it changes
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.hdfsOfNamenodePod),
+			builder.WithPredicates(zkfcStatusChanged)).
+		Watches(&source.Kind{Type: &corev1.Node{}}, handler.EnqueueRequestsFromMapFunc(r.rackAwareHdfs),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		// copy the client configuration to the namespaces labelled for it
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.hdfsSelectingNamespace),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Complete(r)
 }
 
@@ -100,8 +149,8 @@ func (r *HDFSReconciler) internalReconcile(ctx context.Context, hdfs v1.HDFS, st
 	driver := DefaultDriver{
 		Hdfs:   hdfs,
 		Client: r.Client,
-		//ReconcileState:     state,
 		Recorder: r.recorder,
+		ReconcileState: state,
 	}
 	return driver.Reconcile(ctx)
 }
@@ -0,0 +1,297 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"path"
+	"reflect"
+	"regexp"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sort"
+	"strings"
+)
+
+// DirectoryFinalizer lets the operator remove the data of a deleted HDFSDirectory when asked to
+const DirectoryFinalizer = "qy.dataworkbench.com/directory"
+
+// namedACLEntry matches the ACL entries an HDFSDirectory can manage
+var namedACLEntry = regexp.MustCompile(`^(default:)?(user|group):[^:]+:[r-][w-][x-]$`)
+
+// HDFSDirectoryReconciler reconciles a HDFSDirectory object
+type HDFSDirectoryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfsdirectories,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfsdirectories/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfsdirectories/finalizers,verbs=update
+
+// Reconcile provisions the directory of an HDFSDirectory through the WebHDFS API of the referenced
+// cluster and reports its usage.
+func (r *HDFSDirectoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var dir v1.HDFSDirectory
+	if err := r.Client.Get(ctx, req.NamespacedName, &dir); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	var hdfs v1.HDFS
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: dir.ClusterNamespace(), Name: dir.Spec.ClusterRef.Name}, &hdfs)
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+	clusterFound := err == nil
+
+	if !dir.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, r.onDelete(ctx, dir, hdfs, clusterFound)
+	}
+
+	if !controllerutil.ContainsFinalizer(&dir, DirectoryFinalizer) {
+		controllerutil.AddFinalizer(&dir, DirectoryFinalizer)
+		if err := r.Client.Update(ctx, &dir); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	status := *dir.Status.DeepCopy()
+	status.ObservedGeneration = dir.Generation
+	result := pathsDriftRequeue
+	if !clusterFound {
+		status.Phase, status.Message = v1.PathPending, fmt.Sprintf("HDFS %s/%s not found", dir.ClusterNamespace(), dir.Spec.ClusterRef.Name)
+		result = defaultRequeue
+	} else if err := r.checkClaim(ctx, hdfs, dir); err != nil {
+		// checked again once the cluster or the claiming directory change
+		status.Phase, status.Message = v1.PathFailed, err.Error()
+	} else if changed, usage, err := applyDirectory(ctx, webhdfs.ForCluster(hdfs), dir); err != nil {
+		status.Phase, status.Message = v1.PathFailed, err.Error()
+		result = defaultRequeue
+	} else {
+		status.Phase, status.Message, status.Usage = v1.PathApplied, "", &usage
+		if changed {
+			now := metav1.Now()
+			status.LastAppliedTime = &now
+		}
+	}
+
+	if !reflect.DeepEqual(status, dir.Status) {
+		dir.Status = status
+		if err := r.Client.Status().Update(ctx, &dir); err != nil {
+			if errors.IsConflict(err) {
+				return defaultRequeue, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// onDelete removes the directory if its deletion policy asks for it, then releases the HDFSDirectory.
+func (r *HDFSDirectoryReconciler) onDelete(ctx context.Context, dir v1.HDFSDirectory, hdfs v1.HDFS, clusterFound bool) error {
+	if !controllerutil.ContainsFinalizer(&dir, DirectoryFinalizer) {
+		return nil
+	}
+	// the data went away with the cluster
+	if dir.Spec.DeletionPolicy == v1.DirectoryDelete && clusterFound && hdfs.DeletionTimestamp.IsZero() {
+		if path.Clean(dir.Spec.Path) == "/" {
+			return fmt.Errorf("refusing to delete the root directory of HDFS %s", hdfs.Name)
+		}
+		if err := r.checkClaim(ctx, hdfs, dir); err != nil {
+			// the path was never managed by this HDFSDirectory, release it without touching the data
+			log.Info("Not deleting unclaimed HDFS directory", "namespace", dir.Namespace, "name", dir.Name, "reason", err.Error())
+			controllerutil.RemoveFinalizer(&dir, DirectoryFinalizer)
+			return r.Client.Update(ctx, &dir)
+		}
+		log.Info("Deleting HDFS directory", "namespace", dir.Namespace, "name", dir.Name, "path", dir.Spec.Path)
+		err := webhdfs.ForCluster(hdfs).Delete(ctx, dir.Spec.Path, true)
+		if err != nil && !webhdfs.IsNotFound(err) {
+			return err
+		}
+	}
+	controllerutil.RemoveFinalizer(&dir, DirectoryFinalizer)
+	return r.Client.Update(ctx, &dir)
+}
+
+// checkClaim returns an error if the namespace of the HDFSDirectory may not manage its path in the
+// cluster, or if an older HDFSDirectory of the cluster claims the path, one of its parents or of
+// its children.
+func (r *HDFSDirectoryReconciler) checkClaim(ctx context.Context, hdfs v1.HDFS, dir v1.HDFSDirectory) error {
+	if err := tenantAllowed(hdfs, dir); err != nil {
+		return err
+	}
+	var list v1.HDFSDirectoryList
+	if err := r.Client.List(ctx, &list); err != nil {
+		return err
+	}
+	for _, other := range list.Items {
+		if other.UID == dir.UID || other.Spec.ClusterRef.Name != dir.Spec.ClusterRef.Name ||
+			other.ClusterNamespace() != dir.ClusterNamespace() || !olderDirectory(other, dir) ||
+			tenantAllowed(hdfs, other) != nil {
+			continue
+		}
+		if underPath(dir.Spec.Path, other.Spec.Path) || underPath(other.Spec.Path, dir.Spec.Path) {
+			return fmt.Errorf("path %s overlaps %s claimed by HDFSDirectory %s/%s",
+				dir.Spec.Path, other.Spec.Path, other.Namespace, other.Name)
+		}
+	}
+	return nil
+}
+
+// tenantAllowed returns an error if spec.directoryTenants of the cluster does not allow the path
+// of the HDFSDirectory to its namespace.
+func tenantAllowed(hdfs v1.HDFS, dir v1.HDFSDirectory) error {
+	for _, tenant := range hdfs.Spec.DirectoryTenants {
+		if tenant.Namespace != dir.Namespace {
+			continue
+		}
+		if !underPath(dir.Spec.Path, tenant.PathPrefix) {
+			return fmt.Errorf("namespace %s may only manage paths under %s of HDFS %s/%s",
+				dir.Namespace, tenant.PathPrefix, hdfs.Namespace, hdfs.Name)
+		}
+		return nil
+	}
+	if dir.Namespace != hdfs.Namespace {
+		return fmt.Errorf("namespace %s is not a directory tenant of HDFS %s/%s", dir.Namespace, hdfs.Namespace, hdfs.Name)
+	}
+	return nil
+}
+
+// underPath returns true if p is the given directory or one of its descendants
+func underPath(p, dir string) bool {
+	p, dir = path.Clean(p), path.Clean(dir)
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// olderDirectory returns true if a was created before b, by name when created the same second
+func olderDirectory(a, b v1.HDFSDirectory) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+}
+
+// applyDirectory makes the directory match the spec, returning true if anything had to change,
+// along with its usage.
+func applyDirectory(ctx context.Context, c *webhdfs.Client, dir v1.HDFSDirectory) (bool, v1.DirectoryUsage, error) {
+	for _, entry := range dir.Spec.ACLs {
+		if !namedACLEntry.MatchString(entry) {
+			return false, v1.DirectoryUsage{}, fmt.Errorf("invalid ACL entry %q, expected [default:]user|group:<name>:<rwx>", entry)
+		}
+	}
+
+	// ACLs go first as changing them may update the group bits of the permission
+	changed, err := applyACLs(ctx, c, dir.Spec.Path, dir.Spec.ACLs)
+	missing := webhdfs.IsNotFound(err)
+	if err != nil && !missing {
+		return changed, v1.DirectoryUsage{}, err
+	}
+	pathChanged, err := applyPath(ctx, c, dir.Spec.HDFSPath)
+	changed = changed || pathChanged
+	if err != nil {
+		return changed, v1.DirectoryUsage{}, err
+	}
+	if missing {
+		// the directory was just created by applyPath
+		if _, err := applyACLs(ctx, c, dir.Spec.Path, dir.Spec.ACLs); err != nil {
+			return changed, v1.DirectoryUsage{}, err
+		}
+	}
+
+	summary, err := c.GetContentSummary(ctx, dir.Spec.Path)
+	if err != nil {
+		return changed, v1.DirectoryUsage{}, fmt.Errorf("get usage: %w", err)
+	}
+	return changed, v1.DirectoryUsage{
+		Files:         summary.FileCount,
+		Directories:   summary.DirectoryCount,
+		Bytes:         summary.Length,
+		SpaceConsumed: summary.SpaceConsumed,
+	}, nil
+}
+
+// applyACLs replaces the named ACL entries of the path with the given ones.
+func applyACLs(ctx context.Context, c *webhdfs.Client, p string, entries []string) (bool, error) {
+	actual, err := c.GetACLEntries(ctx, p)
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(namedACLEntries(actual), namedACLEntries(entries)) {
+		return false, nil
+	}
+	if err := c.RemoveACL(ctx, p); err != nil {
+		return false, fmt.Errorf("remove ACL: %w", err)
+	}
+	if len(entries) > 0 {
+		if err := c.ModifyACLEntries(ctx, p, entries); err != nil {
+			return true, fmt.Errorf("set ACL: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// namedACLEntries returns the sorted entries naming a user or a group, the base and mask
+// entries being derived from them and the permission.
+func namedACLEntries(entries []string) []string {
+	named := []string{}
+	for _, entry := range entries {
+		if namedACLEntry.MatchString(entry) {
+			named = append(named, entry)
+		}
+	}
+	sort.Strings(named)
+	return named
+}
+
+// directoriesOfCluster returns the requests of the HDFSDirectories referencing the given HDFS cluster.
+func (r *HDFSDirectoryReconciler) directoriesOfCluster(obj client.Object) []reconcile.Request {
+	var list v1.HDFSDirectoryList
+	if err := r.Client.List(context.Background(), &list); err != nil {
+		log.Error(err, "failed to list HDFSDirectory of cluster", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, dir := range list.Items {
+		if dir.Spec.ClusterRef.Name == obj.GetName() && dir.ClusterNamespace() == obj.GetNamespace() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: dir.Namespace, Name: dir.Name}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *HDFSDirectoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.HDFSDirectory{}).
+		// apply the directories once their cluster is created
+		Watches(&source.Kind{Type: &v1.HDFS{}}, handler.EnqueueRequestsFromMapFunc(r.directoriesOfCluster)).
+		Complete(r)
+}
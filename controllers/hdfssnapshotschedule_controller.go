@@ -0,0 +1,133 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	"github.com/dataworkbench/hdfs-operator/controllers/snapshot"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+)
+
+// HDFSSnapshotScheduleReconciler reconciles a HDFSSnapshotSchedule object
+type HDFSSnapshotScheduleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfssnapshotschedules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfssnapshotschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=hdfssnapshotschedules/finalizers,verbs=update
+
+// Reconcile takes the snapshots of an HDFSSnapshotSchedule when they are due and prunes the expired ones.
+// Snapshots are left in place when the schedule is deleted.
+func (r *HDFSSnapshotScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var sched v1.HDFSSnapshotSchedule
+	if err := r.Client.Get(ctx, req.NamespacedName, &sched); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now()
+	status := *sched.Status.DeepCopy()
+	status.ObservedGeneration = sched.Generation
+	result, err := r.run(ctx, sched, &status, now)
+	if err != nil {
+		status.LastFailure = err.Error()
+		status.LastFailureTime = &metav1.Time{Time: now}
+		status.ConsecutiveFailures++
+	}
+
+	if !reflect.DeepEqual(status, sched.Status) {
+		sched.Status = status
+		if err := r.Client.Status().Update(ctx, &sched); err != nil {
+			if errors.IsConflict(err) {
+				return defaultRequeue, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// run runs the schedule and records its outcome in the status.
+func (r *HDFSSnapshotScheduleReconciler) run(ctx context.Context, sched v1.HDFSSnapshotSchedule, status *v1.HDFSSnapshotScheduleStatus, now time.Time) (reconcile.Result, error) {
+	schedule, err := snapshot.ParseSchedule(sched.Spec.Schedule)
+	if err != nil {
+		// nothing to retry until the spec changes
+		return reconcile.Result{}, err
+	}
+
+	var hdfs v1.HDFS
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: sched.ClusterNamespace(), Name: sched.Spec.ClusterRef.Name}, &hdfs)
+	if errors.IsNotFound(err) {
+		return defaultRequeue, fmt.Errorf("HDFS %s/%s not found", sched.ClusterNamespace(), sched.Spec.ClusterRef.Name)
+	} else if err != nil {
+		return defaultRequeue, err
+	}
+
+	lastRun := sched.CreationTimestamp.Time
+	if status.LastSnapshotTime != nil {
+		lastRun = status.LastSnapshotTime.Time
+	}
+	retention := snapshot.Retention{KeepLast: sched.Spec.Retention.KeepLast}
+	if sched.Spec.Retention.MaxAge != nil {
+		retention.MaxAge = &sched.Spec.Retention.MaxAge.Duration
+	}
+
+	outcome, err := snapshot.Run(ctx, webhdfs.ForCluster(hdfs), sched.Spec.Path, sched.Name, schedule, retention,
+		sched.Spec.Suspend, lastRun, now)
+	if !outcome.Next.IsZero() {
+		status.NextSnapshotTime = &metav1.Time{Time: outcome.Next}
+	}
+	if outcome.Taken != "" {
+		log.Info("Took HDFS snapshot", "namespace", sched.Namespace, "schedule", sched.Name, "snapshot", outcome.Taken)
+		status.LastSnapshotName = outcome.Taken
+		status.LastSnapshotTime = &metav1.Time{Time: now}
+		status.ConsecutiveFailures = 0
+	}
+	if err != nil {
+		return defaultRequeue, err
+	}
+	status.Snapshots = int32(len(outcome.Snapshots))
+	if outcome.Next.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{RequeueAfter: outcome.Next.Sub(now) + time.Second}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *HDFSSnapshotScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// status updates must not trigger a run, failures would be counted again
+		For(&v1.HDFSSnapshotSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
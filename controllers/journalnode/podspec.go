@@ -9,6 +9,11 @@ import (
 
 var defaultOptional = true
 
+const (
+	journalPort = 8485
+	httpPort    = 8480
+)
+
 // BuildPodTemplateSpec builds a new PodTemplateSpec for  NameNode.
 func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTemplateSpec, error) {
 	volumes, volumeMounts := buildVolumes(hdfs.Name, hdfs.Spec.Namenode)
@@ -20,8 +25,10 @@ func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTem
 		WithSpecVolumes(volumes...).
 		WithImagePullSecrets(hdfs.Spec.ImagePullSecrets...).
 		WithRestartPolicy(corev1.RestartPolicyAlways).
-		WithHostNetwork(defaultOptional).
-		WithDNSPolicy(corev1.DNSClusterFirstWithHostNet).
+		WithNetwork(hdfs).
+		WithPodSecurity(hdfs).
+		WithDefaultAntiAffinity(labels).
+		WithDefaultTopologySpread(labels).
 		WithTemplateMetadata(labels)
 
 	return builder.PodTemplate, nil
@@ -29,7 +36,7 @@ func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTem
 
 func buildVolumes(name string, nodeSpec v1.NamenodeSet) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
 
-	configVolume := com.NewConfigMapVolume(com.GetName(name, com.CommonConfigName),
+	configVolume := com.NewHdfsConfigVolume(name,
 		com.VolumesConfigMapName,
 		com.HdfsConfigMountPath)
 
@@ -51,15 +58,26 @@ func buildVolumes(name string, nodeSpec v1.NamenodeSet) (volumes []corev1.Volume
 
 func buildContainer(name string, volumeMounts []corev1.VolumeMount, hdfs v1.HDFS) corev1.Container {
 	defaultContainerPorts := getDefaultContainerPorts()
-	return corev1.Container{
+	return defaultProbes().WithProbes(corev1.Container{
 		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
 		Image:           hdfs.Spec.Image,
 		Name:            name,
-		Env:             envVars(),
+		Env:             append(envVars(), com.HeapEnvVars(hdfs.Spec.Version, com.JournalnodeRole, hdfs.Spec.Journalnode.Resources, hdfs.Spec.Journalnode.HeapPercent)...),
 		Command:         []string{"/entrypoint.sh"},
 		Args:            []string{"/opt/hadoop-"+hdfs.Spec.Version+"/bin/hdfs", "--config", "/etc/hadoop", "journalnode"},
 		Ports:           defaultContainerPorts,
 		VolumeMounts:    volumeMounts,
+		Resources:       hdfs.Spec.Journalnode.Resources,
+	}, hdfs.Spec.Journalnode.Probes)
+}
+
+// defaultProbes report the journalnode ready once its JournalNodeInfo bean is served
+func defaultProbes() com.RoleProbes {
+	return com.RoleProbes{
+		Startup: com.TCPProbe(journalPort, 10, 30),
+		Readiness: com.HTTPCheckProbe(httpPort, com.JMXQuery("Hadoop:service=JournalNode,name=JournalNodeInfo"), 10, 3,
+			`"name" *: *"Hadoop:service=JournalNode,name=JournalNodeInfo"`),
+		Liveness: com.TCPProbe(journalPort, 10, 3),
 	}
 }
 
@@ -71,14 +89,14 @@ func envVars() []corev1.EnvVar {
 
 func GetDefaultServicePorts() []corev1.ServicePort {
 	return []corev1.ServicePort{
-		{Name: "jn", Port: 8485},
-		{Name: "http", Port: 8480},
+		{Name: "jn", Port: journalPort},
+		{Name: "http", Port: httpPort},
 	}
 }
 
 func getDefaultContainerPorts() []corev1.ContainerPort {
 	return []corev1.ContainerPort{
-		{Name: "jn", ContainerPort: 8485},
-		{Name: "http", ContainerPort: 8480},
+		{Name: "jn", ContainerPort: journalPort},
+		{Name: "http", ContainerPort: httpPort},
 	}
 }
@@ -3,14 +3,20 @@ package namenode
 import (
 	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/backup"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"strconv"
 )
 
 const (
 	NamenodeScripts = "namenode-scripts"
 	FormatConfigKey = "format-and-run.sh"
+
+	// FormatRefusedMessage starts the termination message of a namenode refusing to format or
+	// to start from the metadata of another cluster.
+	FormatRefusedMessage = "format refused"
 )
 
 func BuildConfigMap(hdfs v1.HDFS) corev1.ConfigMap {
@@ -29,12 +35,13 @@ func BuildConfigMap(hdfs v1.HDFS) corev1.ConfigMap {
 			OwnerReferences: com.GetOwnerReference(hdfs),
 		},
 		Data: map[string]string{
-			FormatConfigKey: getContainerArgsScript(hdfs.Spec.Version),
+			FormatConfigKey: getContainerArgsScript(hdfs),
 		},
 	}
 }
 
-func getContainerArgsScript(version string) string {
+func getContainerArgsScript(hdfs v1.HDFS) string {
+	version := hdfs.Spec.Version
 
 	script := `#!/usr/bin/env bash
     set -o errexit
@@ -48,7 +55,43 @@ func getContainerArgsScript(version string) string {
 
 	containerArgsScript := `/bin/hdfs
     _METADATA_DIR=/hadoop/dfs/name/current
+    _RESTORE_DIR=` + backup.RestoreDir + `
+    _EXPECTED_CLUSTER_ID="` + hdfs.Status.ClusterID + `"
+    _ALLOW_REFORMAT=` + strconv.FormatBool(hdfs.Spec.Namenode.AllowReformat) + `
+    refuse() {
+      echo "` + FormatRefusedMessage + `: $1" | tee /dev/termination-log
+      exit 1
+    }
+    # a swapped or mis-mounted volume must not replace the namespace the datanodes hold blocks of
+    if [[ -d $_METADATA_DIR && -n "$_EXPECTED_CLUSTER_ID" && "$_ALLOW_REFORMAT" != "true" ]]; then
+      _CLUSTER_ID=$(grep '^clusterID=' $_METADATA_DIR/VERSION | cut -d= -f2 || true)
+      if [[ "$_CLUSTER_ID" != "$_EXPECTED_CLUSTER_ID" ]]; then
+        refuse "found metadata of cluster '$_CLUSTER_ID' instead of $_EXPECTED_CLUSTER_ID"
+      fi
+    fi
     if [[ "$MY_POD" = "$NAMENODE_POD_0" ]]; then
+      if [[ ! -d $_METADATA_DIR && -f $_RESTORE_DIR/` + backup.ManifestFileName + ` ]]; then
+          # restore the backup into a storage formatted with the identity of the backed up cluster
+          source $_RESTORE_DIR/` + backup.ManifestFileName + `
+          $_HDFS_BIN --config $HADOOP_CONF_DIR namenode -format  \
+              -nonInteractive -clusterid $CLUSTER_ID ||
+              (rm -rf $_METADATA_DIR; exit 1)
+          _TXID=${IMAGE#fsimage_}
+          _NAMESPACE_ID=$( ($_HDFS_BIN oiv -p XML -i $_RESTORE_DIR/$IMAGE || true) | grep -o -m 1 '<namespaceId>[0-9]*' | grep -o '[0-9]*$')
+          rm -f $_METADATA_DIR/fsimage_*
+          cp $_RESTORE_DIR/$IMAGE $_METADATA_DIR/
+          (cd $_METADATA_DIR && md5sum $IMAGE | sed 's/  / */' > $IMAGE.md5)
+          echo $((10#$_TXID)) > $_METADATA_DIR/seen_txid
+          sed -i -e "s/^namespaceID=.*/namespaceID=$_NAMESPACE_ID/" \
+              -e "s/^blockpoolID=.*/blockpoolID=$BLOCK_POOL_ID/" $_METADATA_DIR/VERSION
+          $_HDFS_BIN --config $HADOOP_CONF_DIR namenode -initializeSharedEdits  \
+              -force -nonInteractive ||
+              (rm -rf $_METADATA_DIR; exit 1)
+          rm -rf $_RESTORE_DIR
+      fi
+      if [[ ! -d $_METADATA_DIR && -n "$_EXPECTED_CLUSTER_ID" && "$_ALLOW_REFORMAT" != "true" ]]; then
+          refuse "no metadata found while cluster $_EXPECTED_CLUSTER_ID is already formatted"
+      fi
       if [[ ! -d $_METADATA_DIR ]]; then
           $_HDFS_BIN --config $HADOOP_CONF_DIR namenode -format  \
               -nonInteractive hdfs-k8s ||
@@ -73,13 +116,11 @@ func getContainerArgsScript(version string) string {
 	if version[0:1] == "3" {
 		hadoopHome = "$HADOOP_HOME"
 		return  script+hadoopHome+containerArgsScript + `
-            nohup $_HDFS_BIN --config $HADOOP_CONF_DIR zkfc &
             $_HDFS_BIN --config $HADOOP_CONF_DIR namenode  `
 	}
 	//return  script+hadoopHome+containerArgsScript +`
     //         $HADOOP_PREFIX/sbin/hadoop-daemon.sh --config $HADOOP_CONF_DIR start zkfc
     //         $_HDFS_BIN --config $HADOOP_CONF_DIR namenode  `
 	return  script+hadoopHome+containerArgsScript +`
-             nohup $_HDFS_BIN --config $HADOOP_CONF_DIR zkfc &
              $_HDFS_BIN --config $HADOOP_CONF_DIR namenode  `
 }
\ No newline at end of file
@@ -3,12 +3,21 @@ package namenode
 import (
 	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/backup"
+	"github.com/dataworkbench/hdfs-operator/controllers/decommission"
+	"github.com/dataworkbench/hdfs-operator/controllers/topology"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
 	ScriptsVolumeName      = "nn-scripts"
 	ScriptsVolumeMountPath = "/nn-scripts"
+
+	// ZkfcContainerName is the sidecar running the failover controller of the namenode
+	ZkfcContainerName = "zkfc"
+	// ZkfcPort is the default dfs.ha.zkfc.port
+	ZkfcPort = 8019
 )
 
 var defaultOptional = true
@@ -16,16 +25,39 @@ var defaultOptional = true
 // BuildPodTemplateSpec builds a new PodTemplateSpec for NameNode.
 func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTemplateSpec, error) {
 	volumes, volumeMounts := buildVolumes(hdfs.Name)
+	if hdfs.Spec.RackAwareness != nil {
+		// the namenode resolves the racks of the datanodes with the topology script
+		topologyVolume := topology.BuildVolume(hdfs)
+		volumes = append(volumes, topologyVolume.Volume())
+		volumeMounts = append(volumeMounts, topologyVolume.VolumeMount())
+	}
+	// the exclude file lists the datanodes being decommissioned
+	excludeVolume := decommission.BuildVolume(hdfs)
+	volumes = append(volumes, excludeVolume.Volume())
+	volumeMounts = append(volumeMounts, excludeVolume.VolumeMount())
 
 	container := buildContainer(com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name), volumeMounts, hdfs)
 
 	builder := &com.PodTemplateBuilder{} //NewPodTemplateBuilder()
+	if hdfs.Spec.Restore != nil {
+		// the first namenode is bootstrapped from the backup downloaded to its metadata volume
+		restore, restoreVolumes, err := backup.BuildRestoreInitContainer(hdfs,
+			envVars(com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name)), metadataVolumeMount())
+		if err != nil {
+			return corev1.PodTemplateSpec{}, err
+		}
+		builder.WithInitContainers(restore)
+		volumes = append(volumes, restoreVolumes...)
+	}
 	builder.WithContainers(container).
+		WithContainers(buildZkfcContainer(hdfs)).
 		WithSpecVolumes(volumes...).
 		WithImagePullSecrets(hdfs.Spec.ImagePullSecrets...).
 		WithRestartPolicy(corev1.RestartPolicyAlways).
-		WithHostNetwork(defaultOptional).
-		WithDNSPolicy(corev1.DNSClusterFirstWithHostNet).
+		WithNetwork(hdfs).
+		WithPodSecurity(hdfs).
+		WithDefaultAntiAffinity(labels).
+		WithDefaultTopologySpread(labels).
 		WithTemplateMetadata(labels)
 
 	return builder.PodTemplate, nil
@@ -33,53 +65,98 @@ func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) (corev1.PodTem
 
 func buildVolumes(name string) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
 
-	configVolume := com.NewConfigMapVolume(com.GetName(name, com.CommonConfigName),
+	configVolume := com.NewHdfsConfigVolume(name,
 		com.VolumesConfigMapName,
 		com.HdfsConfigMountPath)
 
 	scriptsVolume := com.NewConfigMapVolumeWithMode(com.GetName(name, NamenodeScripts),
 		ScriptsVolumeName,
 		ScriptsVolumeMountPath,
-		0744)
+		0755)
 
 	volumes = append(volumes, scriptsVolume.Volume(), configVolume.Volume())
 	volumeMounts = append(volumeMounts, scriptsVolume.VolumeMount(),
 		configVolume.VolumeMount(),
-		corev1.VolumeMount{
-			Name:      NNMetaDataPvcName,
-			MountPath: NNMetaDataVolumeMountPath,
-			SubPath:   "name",
-		})
+		metadataVolumeMount())
 
 	return volumes, volumeMounts
 }
 
+func metadataVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      NNMetaDataPvcName,
+		MountPath: NNMetaDataVolumeMountPath,
+		SubPath:   "name",
+	}
+}
+
 func buildContainer(name string, volumeMounts []corev1.VolumeMount, hdfs v1.HDFS) corev1.Container {
-	defaultContainerPorts := getDefaultContainerPorts()
-	return corev1.Container{
+	defaultContainerPorts := getDefaultContainerPorts(hdfs.Spec.Version)
+	return defaultProbes(hdfs.Spec.Version).WithProbes(corev1.Container{
 		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
 		Image:           hdfs.Spec.Image,
 		Name:            name,
-		Env:             envVars(name),
+		Env:             append(envVars(name), com.HeapEnvVars(hdfs.Spec.Version, com.NamenodeRole, hdfs.Spec.Namenode.Resources, hdfs.Spec.Namenode.HeapPercent)...),
 		Command:         []string{"/bin/sh", "-c"},
 		//Args:            []string{"while true; do echo hello; sleep 10;done"},
 		Args:            []string{"/entrypoint.sh \"/nn-scripts/format-and-run.sh\"" },
 		Ports:           defaultContainerPorts,
 		VolumeMounts:    volumeMounts,
+		Resources:       hdfs.Spec.Namenode.Resources,
+	}, hdfs.Spec.Namenode.Probes)
+}
+
+// defaultProbes wait for the namesystem to be loaded, then report the namenode ready once it
+// holds an HA state and left safemode.
+func defaultProbes(version string) com.RoleProbes {
+	haState := `"tag.HAState" *: *"(active|standby)"`
+	namesystem := com.JMXQuery("Hadoop:service=NameNode,name=FSNamesystem*")
+	return com.RoleProbes{
+		Startup:   com.HTTPCheckProbe(com.NamenodeHttpPort(version), namesystem, 10, 60, haState),
+		Readiness: com.HTTPCheckProbe(com.NamenodeHttpPort(version), namesystem, 10, 3, haState, `"FSState" *: *"Operational"`),
+		Liveness:  com.TCPProbe(com.NamenodeRpcPort(version), 10, 3),
+	}
+}
+
+// buildZkfcContainer builds the ZKFC container, restarted on its own when it stops answering
+// as failover would otherwise silently stop working.
+func buildZkfcContainer(hdfs v1.HDFS) corev1.Container {
+	configVolume := com.NewHdfsConfigVolume(hdfs.Name, com.VolumesConfigMapName, com.HdfsConfigMountPath)
+	return corev1.Container{
+		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
+		Image:           hdfs.Spec.Image,
+		Name:            ZkfcContainerName,
+		Env: []corev1.EnvVar{
+			{Name: "HADOOP_CUSTOM_CONF_DIR", Value: "/etc/hadoop-custom-conf"},
+			{Name: "MULTIHOMED_NETWORK", Value: "0"},
+		},
+		Command: []string{"/entrypoint.sh"},
+		Args:    []string{"/opt/hadoop-" + hdfs.Spec.Version + "/bin/hdfs", "--config", "/etc/hadoop", "zkfc"},
+		Ports:   []corev1.ContainerPort{{Name: "zkfc", ContainerPort: ZkfcPort}},
+		LivenessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(ZkfcPort)},
+			},
+			// the znode is only created once the first namenode is formatted
+			InitialDelaySeconds: 60,
+			PeriodSeconds:       10,
+			FailureThreshold:    3,
+		},
+		VolumeMounts: []corev1.VolumeMount{configVolume.VolumeMount()},
 	}
 }
 
-func GetDefaultServicePorts() []corev1.ServicePort {
+func GetDefaultServicePorts(hdfs v1.HDFS) []corev1.ServicePort {
 	return []corev1.ServicePort{
-		{Name: "http", Port: int32(com.NamenodeHttpPort)},
-		{Name: "fs", Port: int32(com.NamenodeRpcPort)},
+		{Name: "http", Port: int32(com.NamenodeHttpPort(hdfs.Spec.Version))},
+		{Name: "fs", Port: int32(com.NamenodeRpcPort(hdfs.Spec.Version))},
 	}
 }
 
-func getDefaultContainerPorts() []corev1.ContainerPort {
+func getDefaultContainerPorts(version string) []corev1.ContainerPort {
 	return []corev1.ContainerPort{
-		{Name: "http", ContainerPort: int32(com.NamenodeHttpPort)},
-		{Name: "fs", ContainerPort: int32(com.NamenodeRpcPort)},
+		{Name: "http", ContainerPort: int32(com.NamenodeHttpPort(version))},
+		{Name: "fs", ContainerPort: int32(com.NamenodeRpcPort(version))},
 	}
 }
 
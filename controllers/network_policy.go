@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"context"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/networkpolicy"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileNetworkPolicies isolates each role of the cluster, removing the policies of the roles
+// no longer deployed, or all of them once disabled.
+func (d *DefaultDriver) reconcileNetworkPolicies(ctx context.Context) *Results {
+	results := &Results{}
+	expected := map[string]bool{}
+	if d.Hdfs.Spec.NetworkPolicy != nil {
+		for _, policy := range networkpolicy.BuildNetworkPolicies(d.Hdfs) {
+			if _, err := ReconcileNetworkPolicy(d.Client, policy, &d.Hdfs); err != nil {
+				return results.WithError(err)
+			}
+			expected[policy.Name] = true
+		}
+	}
+
+	var policies networkingv1.NetworkPolicyList
+	if err := d.Client.List(ctx, &policies,
+		client.InNamespace(d.Hdfs.Namespace),
+		client.MatchingLabels(com.NewLabels(com.ExtractNamespacedName(&d.Hdfs))),
+	); err != nil {
+		return results.WithError(err)
+	}
+	for i := range policies.Items {
+		if expected[policies.Items[i].Name] {
+			continue
+		}
+		if err := d.Client.Delete(ctx, &policies.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return results.WithError(err)
+		}
+	}
+	return results
+}
@@ -0,0 +1,187 @@
+package networkpolicy
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	dn "github.com/dataworkbench/hdfs-operator/controllers/datanode"
+	jn "github.com/dataworkbench/hdfs-operator/controllers/journalnode"
+	nn "github.com/dataworkbench/hdfs-operator/controllers/namenode"
+	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	zk "github.com/dataworkbench/hdfs-operator/controllers/zookeeper"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+)
+
+// OperatorLabels select the operator pods, calling the WebHDFS API of the namenodes
+var OperatorLabels = map[string]string{"control-plane": "controller-manager"}
+
+// BuildNetworkPolicies returns one policy per role, each allowing the peers of its ports only
+func BuildNetworkPolicies(hdfs v1.HDFS) []networkingv1.NetworkPolicy {
+	spec := hdfs.Spec.NetworkPolicy
+	nsn := com.ExtractNamespacedName(&hdfs)
+	nnName := com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name)
+	jnName := com.GetName(hdfs.Name, hdfs.Spec.Journalnode.Name)
+	namenodes := peer(com.NewStatefulSetLabels(nsn, nnName))
+	// the decommission and backup Jobs
+	jobs := peer(map[string]string{com.TypeLabelName: com.Type, com.JobClusterLabelName: hdfs.Name})
+	operator := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{},
+		PodSelector:       &metav1.LabelSelector{MatchLabels: OperatorLabels},
+	}
+
+	var datanodes []networkingv1.NetworkPolicyPeer
+	for _, pool := range dn.Pools(hdfs) {
+		datanodes = append(datanodes, peer(com.NewStatefulSetLabels(nsn, com.GetName(hdfs.Name, pool.Name))))
+	}
+	// the YARN daemons and the containers they run are HDFS clients
+	var yarnPods []networkingv1.NetworkPolicyPeer
+	withYarn := !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{})
+	rmName := com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name) + "-rm"
+	nmName := com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name) + "-nm"
+	if withYarn {
+		yarnPods = append(yarnPods,
+			peer(com.NewStatefulSetLabels(nsn, rmName)),
+			peer(com.NewStatefulSetLabels(nsn, nmName)))
+		for _, server := range yarnServers(hdfs) {
+			yarnPods = append(yarnPods, peer(com.NewStatefulSetLabels(nsn, server.name)))
+		}
+	}
+	// so are the pods of the YarnClusters running against the cluster
+	yarnClusters := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{
+		MatchLabels: com.NewLabels(nsn),
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      com.YarnClusterLabelName,
+			Operator: metav1.LabelSelectorOpExists,
+		}},
+	}}
+	clients := append(append([]networkingv1.NetworkPolicyPeer{yarnClusters}, yarnPods...), spec.Clients...)
+
+	policies := []networkingv1.NetworkPolicy{
+		build(hdfs, nnName,
+			rule(peers(datanodes, []networkingv1.NetworkPolicyPeer{namenodes, jobs}, clients), com.NamenodeRpcPort(hdfs.Spec.Version)),
+			rule(peers([]networkingv1.NetworkPolicyPeer{namenodes, jobs, operator}, spec.Clients, spec.Metrics), com.NamenodeHttpPort(hdfs.Spec.Version)),
+			rule([]networkingv1.NetworkPolicyPeer{namenodes}, nn.ZkfcPort)),
+		build(hdfs, jnName,
+			rule([]networkingv1.NetworkPolicyPeer{namenodes}, servicePort(jn.GetDefaultServicePorts(), "jn")),
+			rule(peers([]networkingv1.NetworkPolicyPeer{namenodes, peer(com.NewStatefulSetLabels(nsn, jnName))}, spec.Metrics),
+				servicePort(jn.GetDefaultServicePorts(), "http"))),
+	}
+	for _, pool := range dn.Pools(hdfs) {
+		policies = append(policies, build(hdfs, com.GetName(hdfs.Name, pool.Name),
+			rule(peers(datanodes, clients), int(dn.DataPort(hdfs)), int(dn.IPCPort(hdfs))),
+			rule(peers(spec.Clients, spec.Metrics), com.DatanodeRpcPort(hdfs.Spec.Version))))
+	}
+	if com.EmbeddedZookeeper(hdfs) {
+		zkName := com.ZookeeperName(hdfs)
+		policies = append(policies, build(hdfs, zkName,
+			rule(peers([]networkingv1.NetworkPolicyPeer{namenodes, yarnClusters}, yarnPods), com.ZookeeperClientPort),
+			rule([]networkingv1.NetworkPolicyPeer{peer(com.NewStatefulSetLabels(nsn, zkName))},
+				servicePort(zk.GetDefaultServicePorts(), "server"), servicePort(zk.GetDefaultServicePorts(), "election"))))
+	}
+	if withYarn {
+		var rmPorts []int
+		for _, port := range yarn.GetRMServicePorts() {
+			rmPorts = append(rmPorts, int(port.Port))
+		}
+		// the application masters and their containers run on the NodeManagers on any port,
+		// the operator follows the HA state and the metrics of the ResourceManagers
+		policies = append(policies,
+			build(hdfs, rmName,
+				rule(peers(yarnPods, []networkingv1.NetworkPolicyPeer{jobs}, spec.Clients), rmPorts...),
+				rule(peers([]networkingv1.NetworkPolicyPeer{operator}, spec.Metrics), servicePort(yarn.GetRMServicePorts(), "web"))),
+			build(hdfs, nmName,
+				rule(yarnPods),
+				rule(peers(spec.Clients, spec.Metrics), servicePort(yarn.GetNMServicePorts(), "web"))))
+		for _, server := range yarnServers(hdfs) {
+			var ports []int
+			for _, port := range server.ports {
+				ports = append(ports, int(port.Port))
+			}
+			policies = append(policies, build(hdfs, server.name,
+				rule(peers(yarnPods, spec.Clients), ports...),
+				rule(spec.Metrics, servicePort(server.ports, "web"))))
+		}
+	}
+	return policies
+}
+
+// build returns the policy selecting the pods of the StatefulSet, allowing the given rules only
+func build(hdfs v1.HDFS, ssetName string, rules ...networkingv1.NetworkPolicyIngressRule) networkingv1.NetworkPolicy {
+	nsn := com.ExtractNamespacedName(&hdfs)
+	var ingress []networkingv1.NetworkPolicyIngressRule
+	for _, r := range rules {
+		// a rule without peers would allow every source
+		if len(r.From) > 0 {
+			ingress = append(ingress, r)
+		}
+	}
+	return networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            ssetName,
+			Labels:          com.NewLabels(nsn),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: com.NewStatefulSetLabels(nsn, ssetName)},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}
+}
+
+type yarnServer struct {
+	name  string
+	ports []corev1.ServicePort
+}
+
+// yarnServers returns the JobHistory and timeline servers run
+func yarnServers(hdfs v1.HDFS) []yarnServer {
+	var servers []yarnServer
+	if hdfs.Spec.Yarn.HistoryServer != nil {
+		servers = append(servers, yarnServer{com.YarnServerName(hdfs, com.JobHistorySuffix), yarn.GetJobHistoryServicePorts()})
+	}
+	if hdfs.Spec.Yarn.TimelineServer != nil {
+		servers = append(servers, yarnServer{com.YarnServerName(hdfs, com.TimelineSuffix), yarn.GetTimelineServicePorts()})
+	}
+	return servers
+}
+
+// rule allows the peers to reach the TCP ports, all ports if none
+func rule(from []networkingv1.NetworkPolicyPeer, ports ...int) networkingv1.NetworkPolicyIngressRule {
+	r := networkingv1.NetworkPolicyIngressRule{From: from}
+	for _, port := range ports {
+		protocol := corev1.ProtocolTCP
+		p := intstr.FromInt(port)
+		r.Ports = append(r.Ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+	}
+	return r
+}
+
+func peer(labels map[string]string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: labels}}
+}
+
+func peers(lists ...[]networkingv1.NetworkPolicyPeer) []networkingv1.NetworkPolicyPeer {
+	var all []networkingv1.NetworkPolicyPeer
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+func servicePort(ports []corev1.ServicePort, name string) int {
+	for _, port := range ports {
+		if port.Name == name {
+			return int(port.Port)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package networkpolicy
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testHDFS() v1.HDFS {
+	return v1.HDFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "default"},
+		Spec: v1.HDFSSpec{
+			Version:     "3.2.2",
+			Namenode:    v1.NamenodeSet{Name: "nn", Replicas: 2},
+			Journalnode: v1.Journalnode{Name: "jn", Replicas: 3},
+			Datanode:    v1.Datanode{Name: "dn", Replicas: 3},
+			DatanodePools: []v1.DatanodePool{
+				{Datanode: v1.Datanode{Name: "ssd", Replicas: 2}},
+			},
+			Zookeeper: &v1.Zookeeper{Replicas: 3},
+			Yarn:      v1.Yarn{Name: "yarn", RMReplicas: 2, NMReplicas: 2},
+			NetworkPolicy: &v1.NetworkPolicy{
+				Clients: []networkingv1.NetworkPolicyPeer{peer(map[string]string{"app": "client"})},
+			},
+		},
+	}
+}
+
+func policiesByName(hdfs v1.HDFS) map[string]networkingv1.NetworkPolicy {
+	policies := map[string]networkingv1.NetworkPolicy{}
+	for _, policy := range BuildNetworkPolicies(hdfs) {
+		policies[policy.Name] = policy
+	}
+	return policies
+}
+
+// allowed returns true if a rule of the policy lets the peer reach the port
+func allowed(policy networkingv1.NetworkPolicy, from networkingv1.NetworkPolicyPeer, port int) bool {
+	for _, r := range policy.Spec.Ingress {
+		portAllowed := len(r.Ports) == 0
+		for _, p := range r.Ports {
+			portAllowed = portAllowed || p.Port.IntValue() == port
+		}
+		for _, f := range r.From {
+			if portAllowed && reflect.DeepEqual(f, from) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func yarnClusterPeer(hdfs v1.HDFS) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{
+		MatchLabels: com.NewLabels(com.ExtractNamespacedName(&hdfs)),
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      com.YarnClusterLabelName,
+			Operator: metav1.LabelSelectorOpExists,
+		}},
+	}}
+}
+
+func TestPoliciesPerRole(t *testing.T) {
+	policies := policiesByName(testHDFS())
+	for _, name := range []string{"hdfs-nn", "hdfs-jn", "hdfs-dn", "hdfs-ssd", "hdfs-zk", "hdfs-yarn-rm", "hdfs-yarn-nm"} {
+		policy, ok := policies[name]
+		if !ok {
+			t.Errorf("no policy %s", name)
+			continue
+		}
+		if len(policy.Spec.Ingress) == 0 {
+			t.Errorf("%s: no ingress rule", name)
+		}
+		for _, r := range policy.Spec.Ingress {
+			if len(r.From) == 0 {
+				t.Errorf("%s: rule allowing every source", name)
+			}
+		}
+	}
+	if len(policies) != 7 {
+		t.Errorf("expected 7 policies, got %d", len(policies))
+	}
+}
+
+func TestDatanodesOfEveryPoolReachTheNamenodes(t *testing.T) {
+	hdfs := testHDFS()
+	nn := policiesByName(hdfs)["hdfs-nn"]
+	nsn := com.ExtractNamespacedName(&hdfs)
+	for _, ssetName := range []string{"hdfs-dn", "hdfs-ssd"} {
+		if !allowed(nn, peer(com.NewStatefulSetLabels(nsn, ssetName)), com.NamenodeRpcPort(hdfs.Spec.Version)) {
+			t.Errorf("%s cannot reach the namenode RPC port", ssetName)
+		}
+	}
+}
+
+func TestYarnClustersReachZookeeper(t *testing.T) {
+	hdfs := testHDFS()
+	policies := policiesByName(hdfs)
+	if !allowed(policies["hdfs-zk"], yarnClusterPeer(hdfs), com.ZookeeperClientPort) {
+		t.Error("YarnCluster pods cannot reach the ZooKeeper client port")
+	}
+	if !allowed(policies["hdfs-nn"], yarnClusterPeer(hdfs), com.NamenodeRpcPort(hdfs.Spec.Version)) {
+		t.Error("YarnCluster pods cannot reach the namenode RPC port")
+	}
+	client := hdfs.Spec.NetworkPolicy.Clients[0]
+	if allowed(policies["hdfs-zk"], client, com.ZookeeperClientPort) {
+		t.Error("HDFS clients should not reach ZooKeeper")
+	}
+}
+
+func TestWithoutYarn(t *testing.T) {
+	hdfs := testHDFS()
+	hdfs.Spec.Yarn = v1.Yarn{}
+	policies := policiesByName(hdfs)
+	if _, ok := policies["hdfs-yarn-rm"]; ok {
+		t.Error("unexpected ResourceManager policy without YARN")
+	}
+	if !allowed(policies["hdfs-zk"], yarnClusterPeer(hdfs), com.ZookeeperClientPort) {
+		t.Error("YarnCluster pods cannot reach the ZooKeeper client port")
+	}
+}
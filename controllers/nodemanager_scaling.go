@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"context"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+)
+
+// reconcileNodeManagerScaling scales the NodeManagers of spec.yarn.autoscaling.
+func (d *DefaultDriver) reconcileNodeManagerScaling(ctx context.Context) *Results {
+	results := &Results{}
+	if reflect.DeepEqual(d.Hdfs.Spec.Yarn, v1.Yarn{}) || d.Hdfs.Spec.Yarn.Autoscaling == nil {
+		return results
+	}
+	if err := scaleNodeManagers(ctx, d.Client, d.Hdfs, func(metav1.Object) {}); err != nil {
+		return results.WithError(err)
+	}
+	return results
+}
+
+// keepNodeManagerReplicas sets the autoscaled replicas on the expected NodeManager StatefulSet,
+// for a StatefulSet re-created to expand its claims not to fall back to its initial replicas.
+func (d *DefaultDriver) keepNodeManagerReplicas(ctx context.Context, expected []appsv1.StatefulSet) error {
+	if d.Hdfs.Spec.Yarn.Autoscaling == nil {
+		return nil
+	}
+	for i := range expected {
+		if expected[i].Name != yarn.NMStatefulSetName(d.Hdfs) {
+			continue
+		}
+		var actual appsv1.StatefulSet
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: expected[i].Name}, &actual)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		expected[i].Spec.Replicas = actual.Spec.Replicas
+	}
+	return nil
+}
+
+// scaleNodeManagers moves the NodeManager StatefulSet one replica at a time towards the
+// NodeManagers the pending and allocated resources call for, adopt setting the owner of the
+// objects it creates. NodeManagers are added right away. They are removed in three steps: the
+// exclude file lists them and a job has the ResourceManagers decommission them gracefully, the
+// StatefulSet is scaled down once the job completed, and the file is emptied once the pods are gone.
+// A failed job is deleted and run again.
+func scaleNodeManagers(ctx context.Context, c client.Client, hdfs v1.HDFS, adopt func(metav1.Object)) error {
+	var sset appsv1.StatefulSet
+	err := c.Get(ctx, types.NamespacedName{Namespace: hdfs.Namespace, Name: yarn.NMStatefulSetName(hdfs)}, &sset)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	created := err == nil
+
+	var actual corev1.ConfigMap
+	err = c.Get(ctx, types.NamespacedName{Namespace: hdfs.Namespace, Name: yarn.ExcludeConfigMapName(hdfs)}, &actual)
+	if apierrors.IsNotFound(err) {
+		// the ResourceManagers need the exclude file to start
+		replicas, err := yarn.InitialNMReplicas(hdfs)
+		if err != nil {
+			return err
+		}
+		if created {
+			replicas = *sset.Spec.Replicas
+		}
+		expected := yarn.BuildExcludeConfigMap(hdfs, "", replicas)
+		adopt(&expected)
+		return reconcileExcludeConfigMap(c, expected)
+	}
+	if err != nil || !created {
+		return err
+	}
+
+	replicas := *sset.Spec.Replicas
+	target := replicas
+	if n, err := strconv.Atoi(actual.Annotations[yarn.NMReplicasAnnotation]); err == nil {
+		target = int32(n)
+	}
+	exclude := actual.Data[com.NMExcludeFileName]
+
+	var job batchv1.Job
+	err = c.Get(ctx, types.NamespacedName{Namespace: hdfs.Namespace, Name: yarn.JobName(hdfs, exclude)}, &job)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if jobHasCondition(job, batchv1.JobFailed) {
+			// the ResourceManagers may not have read the exclude file, the next
+			// reconciliation runs the job again
+			log.Info("Refreshing the NodeManagers failed, retrying", "namespace", job.Namespace, "name", job.Name)
+			return deleteJob(ctx, c, job)
+		}
+		if !jobHasCondition(job, batchv1.JobComplete) {
+			return nil
+		}
+		// the listed NodeManagers are decommissioned, or their timeout expired
+		if replicas > target {
+			log.Info("Removing decommissioned NodeManagers", "namespace", sset.Namespace, "name", sset.Name,
+				"from", replicas, "to", target)
+			sset.Spec.Replicas = &target
+			if err := c.Update(ctx, &sset); err != nil {
+				return err
+			}
+		}
+		return deleteJob(ctx, c, job)
+	}
+
+	removed, err := nodeManagersAbove(ctx, c, hdfs, target)
+	if err != nil {
+		return err
+	}
+	if expected := yarn.BuildExclude(hdfs, removed); expected != exclude || target < replicas {
+		return refreshExclude(c, hdfs, adopt, expected, target)
+	}
+	if target > replicas {
+		sset.Spec.Replicas = &target
+		return c.Update(ctx, &sset)
+	}
+
+	// let the last change settle before the next one
+	if sset.Status.ObservedGeneration < sset.Generation ||
+		sset.Status.Replicas != replicas || sset.Status.ReadyReplicas != replicas {
+		return nil
+	}
+	metrics, err := yarn.ForCluster(hdfs).GetClusterMetrics(ctx)
+	if err != nil {
+		log.Info("Cannot get the YARN cluster metrics", "namespace", hdfs.Namespace, "name", hdfs.Name, "error", err.Error())
+		return nil
+	}
+	if metrics.ActiveNodes != replicas {
+		// new NodeManagers are not registered yet
+		return nil
+	}
+
+	desired := yarn.DesiredNodeManagers(*hdfs.Spec.Yarn.Autoscaling, replicas, metrics)
+	switch {
+	case desired > replicas:
+		log.Info("Adding NodeManagers", "namespace", sset.Namespace, "name", sset.Name,
+			"from", replicas, "to", desired, "pendingMB", metrics.PendingMB, "pendingVCores", metrics.PendingVirtualCores)
+		expected := yarn.BuildExcludeConfigMap(hdfs, exclude, desired)
+		adopt(&expected)
+		if err := reconcileExcludeConfigMap(c, expected); err != nil {
+			return err
+		}
+		sset.Spec.Replicas = &desired
+		return c.Update(ctx, &sset)
+	case desired < replicas:
+		log.Info("Decommissioning NodeManagers", "namespace", sset.Namespace, "name", sset.Name,
+			"from", replicas, "to", desired, "allocatedMB", metrics.AllocatedMB, "totalMB", metrics.TotalMB)
+		removed, err := nodeManagersAbove(ctx, c, hdfs, desired)
+		if err != nil {
+			return err
+		}
+		return refreshExclude(c, hdfs, adopt, yarn.BuildExclude(hdfs, removed), desired)
+	}
+	return nil
+}
+
+// refreshExclude has the ResourceManagers read the given exclude file. The job is created first,
+// waiting for the file to reach the ResourceManagers.
+func refreshExclude(c client.Client, hdfs v1.HDFS, adopt func(metav1.Object), exclude string, target int32) error {
+	job := yarn.BuildRefreshNodesJob(hdfs, exclude)
+	adopt(&job)
+	if _, err := ReconcileJob(c, job, nil); err != nil {
+		return err
+	}
+	expected := yarn.BuildExcludeConfigMap(hdfs, exclude, target)
+	adopt(&expected)
+	return reconcileExcludeConfigMap(c, expected)
+}
+
+// reconcileExcludeConfigMap creates or updates the exclude config map, with its annotation
+func reconcileExcludeConfigMap(c client.Client, expected corev1.ConfigMap) error {
+	var reconciled corev1.ConfigMap
+	return ReconcileResource(Params{
+		Client:     c,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return !reflect.DeepEqual(expected.Data, reconciled.Data) ||
+				reconciled.Annotations[yarn.NMReplicasAnnotation] != expected.Annotations[yarn.NMReplicasAnnotation]
+		},
+		UpdateReconciled: func() {
+			reconciled.Data = expected.Data
+			if reconciled.Annotations == nil {
+				reconciled.Annotations = map[string]string{}
+			}
+			reconciled.Annotations[yarn.NMReplicasAnnotation] = expected.Annotations[yarn.NMReplicasAnnotation]
+		},
+	})
+}
+
+// nodeManagersAbove returns the NodeManager pods the StatefulSet removes when scaled to the given replicas
+func nodeManagersAbove(ctx context.Context, c client.Client, hdfs v1.HDFS, replicas int32) ([]corev1.Pod, error) {
+	return statefulSetPodsAbove(ctx, c, hdfs, yarn.NMStatefulSetName(hdfs), replicas)
+}
+
+// statefulSetPodsAbove returns the pods of the StatefulSet with an ordinal at or above the given replicas
+func statefulSetPodsAbove(ctx context.Context, c client.Client, hdfs v1.HDFS, ssetName string, replicas int32) ([]corev1.Pod, error) {
+	var list corev1.PodList
+	if err := c.List(ctx, &list,
+		client.InNamespace(hdfs.Namespace),
+		client.MatchingLabels(com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), ssetName)),
+	); err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range list.Items {
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, ssetName+"-"))
+		if err == nil && int32(ordinal) >= replicas {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// deleteJob deletes the job along with its pods
+func deleteJob(ctx context.Context, c client.Client, job batchv1.Job) error {
+	err := c.Delete(ctx, &job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func jobHasCondition(job batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
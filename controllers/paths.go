@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strconv"
+	"time"
+)
+
+const PathsAppliedCondition = "PathsApplied"
+
+// pathsDriftRequeue is how often the paths are checked for changes made outside of the operator
+var pathsDriftRequeue = reconcile.Result{RequeueAfter: 5 * time.Minute}
+
+// namenodesReady returns true once a namenode of the cluster is running.
+func namenodesReady(ctx context.Context, d *DefaultDriver) (bool, error) {
+	var sset appsv1.StatefulSet
+	nsn := types.NamespacedName{Namespace: d.Hdfs.Namespace, Name: com.GetName(d.Hdfs.Name, d.Hdfs.Spec.Namenode.Name)}
+	err := d.Client.Get(ctx, nsn, &sset)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return sset.Status.ReadyReplicas > 0, nil
+}
+
+// reconcilePaths applies spec.paths through WebHDFS and reports the outcome of each path.
+func (d *DefaultDriver) reconcilePaths(ctx context.Context) *Results {
+	results := &Results{}
+	if len(d.Hdfs.Spec.Paths) == 0 {
+		d.ReconcileState.UpdatePaths(nil).RemoveCondition(PathsAppliedCondition)
+		return results
+	}
+
+	previous := map[string]v1.PathStatus{}
+	for _, status := range d.Hdfs.Status.Paths {
+		previous[status.Path] = status
+	}
+
+	ready, err := namenodesReady(ctx, d)
+	if err != nil {
+		return results.WithError(err)
+	}
+	if !ready {
+		var statuses []v1.PathStatus
+		for _, p := range d.Hdfs.Spec.Paths {
+			status := previous[p.Path]
+			status.Path, status.Phase, status.Message = p.Path, v1.PathPending, "waiting for the namenodes"
+			statuses = append(statuses, status)
+		}
+		d.ReconcileState.UpdatePaths(statuses)
+		d.ReconcileState.SetCondition(PathsAppliedCondition, metav1.ConditionFalse, "NamenodesNotReady", "waiting for the namenodes")
+		return results.WithResult(defaultRequeue)
+	}
+
+	client := webhdfs.ForCluster(d.Hdfs)
+	var statuses []v1.PathStatus
+	failed := 0
+	for _, p := range d.Hdfs.Spec.Paths {
+		status := previous[p.Path]
+		status.Path = p.Path
+		changed, err := applyPath(ctx, client, p)
+		if err != nil {
+			failed++
+			status.Phase, status.Message = v1.PathFailed, err.Error()
+		} else {
+			status.Phase, status.Message = v1.PathApplied, ""
+			if changed {
+				now := metav1.Now()
+				status.LastAppliedTime = &now
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	d.ReconcileState.UpdatePaths(statuses)
+
+	if failed > 0 {
+		d.ReconcileState.SetCondition(PathsAppliedCondition, metav1.ConditionFalse, "ApplyFailed",
+			fmt.Sprintf("%d of %d paths could not be applied", failed, len(d.Hdfs.Spec.Paths)))
+		return results.WithResult(defaultRequeue)
+	}
+	d.ReconcileState.SetCondition(PathsAppliedCondition, metav1.ConditionTrue, "Applied", "")
+	return results.WithResult(pathsDriftRequeue)
+}
+
+// applyPath makes the directory match its spec, returning true if anything had to change.
+func applyPath(ctx context.Context, c *webhdfs.Client, p v1.HDFSPath) (bool, error) {
+	changed := false
+
+	status, err := c.GetFileStatus(ctx, p.Path)
+	if webhdfs.IsNotFound(err) {
+		if err := c.Mkdirs(ctx, p.Path); err != nil {
+			return changed, fmt.Errorf("create directory: %w", err)
+		}
+		changed = true
+		if status, err = c.GetFileStatus(ctx, p.Path); err != nil {
+			return changed, err
+		}
+	} else if err != nil {
+		return changed, err
+	}
+	if status.Type != "DIRECTORY" {
+		return changed, fmt.Errorf("%s is not a directory", p.Path)
+	}
+
+	if (p.Owner != "" && p.Owner != status.Owner) || (p.Group != "" && p.Group != status.Group) {
+		if err := c.SetOwner(ctx, p.Path, p.Owner, p.Group); err != nil {
+			return changed, fmt.Errorf("set owner: %w", err)
+		}
+		changed = true
+	}
+
+	if p.Mode != "" && !sameMode(p.Mode, status.Permission) {
+		if err := c.SetPermission(ctx, p.Path, p.Mode); err != nil {
+			return changed, fmt.Errorf("set permission: %w", err)
+		}
+		changed = true
+	}
+
+	if p.StoragePolicy != "" {
+		policy, err := c.GetStoragePolicy(ctx, p.Path)
+		if err != nil {
+			return changed, fmt.Errorf("get storage policy: %w", err)
+		}
+		if policy != p.StoragePolicy {
+			if err := c.SetStoragePolicy(ctx, p.Path, p.StoragePolicy); err != nil {
+				return changed, fmt.Errorf("set storage policy: %w", err)
+			}
+			changed = true
+		}
+	}
+
+	if p.ECPolicy != "" && p.ECPolicy != status.ECPolicy {
+		if err := c.EnableECPolicy(ctx, p.ECPolicy); err != nil {
+			return changed, fmt.Errorf("enable erasure coding policy: %w", err)
+		}
+		if err := c.SetECPolicy(ctx, p.Path, p.ECPolicy); err != nil {
+			return changed, fmt.Errorf("set erasure coding policy: %w", err)
+		}
+		changed = true
+	}
+
+	if p.NameQuota != nil || p.SpaceQuota != nil {
+		summary, err := c.GetContentSummary(ctx, p.Path)
+		if err != nil {
+			return changed, fmt.Errorf("get quotas: %w", err)
+		}
+		nameQuota, spaceQuota := webhdfs.QuotaDontSet, webhdfs.QuotaDontSet
+		if p.NameQuota != nil && *p.NameQuota != summary.Quota {
+			nameQuota = *p.NameQuota
+		}
+		if p.SpaceQuota != nil && p.SpaceQuota.Value() != summary.SpaceQuota {
+			spaceQuota = p.SpaceQuota.Value()
+		}
+		if nameQuota != webhdfs.QuotaDontSet || spaceQuota != webhdfs.QuotaDontSet {
+			if err := c.SetQuota(ctx, p.Path, nameQuota, spaceQuota); err != nil {
+				return changed, fmt.Errorf("set quota: %w", err)
+			}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// sameMode compares octal permissions, ignoring leading zeros.
+func sameMode(want, have string) bool {
+	w, err := strconv.ParseUint(want, 8, 32)
+	if err != nil {
+		return false
+	}
+	h, err := strconv.ParseUint(have, 8, 32)
+	return err == nil && w == h
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	dn "github.com/dataworkbench/hdfs-operator/controllers/datanode"
+	"github.com/dataworkbench/hdfs-operator/controllers/topology"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconcileRackTopology maintains the datanode host to rack mapping read by the namenode topology script.
+func (d *DefaultDriver) reconcileRackTopology(ctx context.Context) *Results {
+	results := &Results{}
+	if d.Hdfs.Spec.RackAwareness == nil {
+		return results
+	}
+
+	pods, err := listPoolPods(ctx, d.Client, d.Hdfs, dn.Pools(d.Hdfs))
+	if err != nil {
+		return results.WithError(err)
+	}
+
+	nodes := map[string]corev1.Node{}
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		if _, exists := nodes[nodeName]; nodeName == "" || exists {
+			continue
+		}
+		var node corev1.Node
+		err := d.Client.Get(ctx, types.NamespacedName{Name: nodeName}, &node)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return results.WithError(err)
+		}
+		nodes[nodeName] = node
+	}
+
+	expected := topology.BuildConfigMap(d.Hdfs, pods, nodes)
+	if _, err := ReconcileConfigMap(d.Client, expected, &d.Hdfs); err != nil {
+		return results.WithError(err)
+	}
+	return results
+}
+
+// datanodeLocationChanged filters pod events down to the ones that may move a datanode to another rack.
+var datanodeLocationChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPod, ok := e.ObjectOld.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+		newPod, ok := e.ObjectNew.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+		return oldPod.Spec.NodeName != newPod.Spec.NodeName ||
+			oldPod.Status.PodIP != newPod.Status.PodIP ||
+			oldPod.Status.HostIP != newPod.Status.HostIP
+	},
+}
+
+// hdfsOfPod returns the request of the HDFS cluster the pod belongs to, if the cluster tracks
+// the datanode addresses for its rack topology and exclude file, or advertises the nodes of its
+// pods to external clients.
+func (r *HDFSReconciler) hdfsOfPod(obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels[com.TypeLabelName] != com.Type || labels[com.ClusterNameLabelName] == "" {
+		return nil
+	}
+	var hdfs v1.HDFS
+	nsn := types.NamespacedName{Namespace: obj.GetNamespace(), Name: labels[com.ClusterNameLabelName]}
+	if err := r.Client.Get(context.Background(), nsn, &hdfs); err != nil {
+		return nil
+	}
+	ssetName := labels[com.StatefulSetLabel]
+	if hdfs.Spec.ExternalAccess != nil && hdfs.Spec.ExternalAccess.Type == corev1.ServiceTypeNodePort &&
+		ssetName == com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name) {
+		return []reconcile.Request{{NamespacedName: nsn}}
+	}
+	// any datanode may be excluded while its pool scales down
+	if !isDatanodePod(hdfs, ssetName) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: nsn}}
+}
+
+// rackAwareHdfs returns the requests of every rack aware HDFS cluster, as a node label change may
+// move any of their datanodes to another rack.
+func (r *HDFSReconciler) rackAwareHdfs(_ client.Object) []reconcile.Request {
+	var list v1.HDFSList
+	if err := r.Client.List(context.Background(), &list); err != nil {
+		log.Error(err, "failed to list HDFS for node topology change")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, hdfs := range list.Items {
+		if hdfs.Spec.RackAwareness != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: com.ExtractNamespacedName(&hdfs)})
+		}
+	}
+	return requests
+}
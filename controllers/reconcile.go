@@ -28,7 +28,8 @@ type Params struct {
 	// unification of remote and expected state.
 	Reconciled client.Object
 	// NeedsUpdate returns true when the object to be reconciled has changes that are not persisted remotely.
-	//NeedsUpdate func() bool
+	// Existing resources are left untouched when it is nil.
+	NeedsUpdate func() bool
 	// NeedsRecreate returns true when the object to be reconciled needs to be deleted and re-created because it cannot be updated.
 	//NeedsRecreate func() bool
 	// UpdateReconciled modifies the resource pointed to by Reconciled to reflect the state of Expected
@@ -38,19 +39,16 @@ type Params struct {
 	// PreUpdate is called just before the update of the resource.
 	PreUpdate func() error
 	// PostUpdate is called immediately after the resource is successfully updated.
-	//PostUpdate func()
+	PostUpdate func()
 }
 
 func (p Params) CheckNilValues() error {
 	if p.Reconciled == nil {
 		return errors.New("Reconciled must not be nil")
 	}
-	//if p.UpdateReconciled == nil {
-	//	return errors.New("UpdateReconciled must not be nil")
-	//}
-	//if p.NeedsUpdate == nil {
-	//	return errors.New("NeedsUpdate must not be nil")
-	//}
+	if p.NeedsUpdate != nil && p.UpdateReconciled == nil {
+		return errors.New("UpdateReconciled must not be nil when NeedsUpdate is set")
+	}
 	if p.Expected == nil {
 		return errors.New("Expected must not be nil")
 	}
@@ -105,5 +103,24 @@ func ReconcileResource(params Params) error {
 		return fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, err)
 	}
 
+	// Update if needed
+	if params.NeedsUpdate != nil && params.NeedsUpdate() {
+		log.Info("Updating resource", "kind", kind, "namespace", namespace, "name", name)
+		if params.PreUpdate != nil {
+			if err := params.PreUpdate(); err != nil {
+				return err
+			}
+		}
+		// Update the resource
+		params.UpdateReconciled()
+		err = params.Client.Update(context.Background(), params.Reconciled)
+		if err != nil {
+			return err
+		}
+		if params.PostUpdate != nil {
+			params.PostUpdate()
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	"github.com/dataworkbench/hdfs-operator/controllers/external"
+	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"reflect"
+)
+
+// reconcileResourceManagers labels the ResourceManager pods with their HA state for the Service
+// of the active one to follow failovers, checked on every requeue.
+func (d *DefaultDriver) reconcileResourceManagers(ctx context.Context) *Results {
+	results := &Results{}
+	if reflect.DeepEqual(d.Hdfs.Spec.Yarn, v1.Yarn{}) {
+		var svc corev1.Service
+		svc.Namespace, svc.Name = d.Hdfs.Namespace, yarn.ActiveRMServiceName(d.Hdfs)
+		if err := d.Client.Delete(ctx, &svc); err != nil && !apierrors.IsNotFound(err) {
+			return results.WithError(err)
+		}
+		return results
+	}
+
+	podNames := external.PodNames(yarn.RMStatefulSetName(d.Hdfs), d.Hdfs.Spec.Yarn.RMReplicas)
+	if err := d.labelHAStates(ctx, podNames, yarn.ForCluster(d.Hdfs).GetHAStates(ctx)); err != nil {
+		return results.WithError(err)
+	}
+	if _, err := ReconcileService(d.Client, ptrService(yarn.BuildActiveRMService(d.Hdfs)), &d.Hdfs); err != nil {
+		return results.WithError(err)
+	}
+	return results.WithResult(defaultRequeue)
+}
@@ -1,30 +1,55 @@
 package controllers
 
 import (
+	"errors"
 	"github.com/dataworkbench/hdfs-operator/api/v1"
 	com "github.com/dataworkbench/hdfs-operator/common"
 	dn "github.com/dataworkbench/hdfs-operator/controllers/datanode"
 	jn "github.com/dataworkbench/hdfs-operator/controllers/journalnode"
 	nn "github.com/dataworkbench/hdfs-operator/controllers/namenode"
 	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	zk "github.com/dataworkbench/hdfs-operator/controllers/zookeeper"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"reflect"
 )
 
 type HdfsResources struct {
 	StatefulSets  []appsv1.StatefulSet
 	Datanode      appsv1.StatefulSet
+	DatanodePools []appsv1.StatefulSet
+	// Zookeeper is the embedded ensemble, if any
+	Zookeeper     []appsv1.StatefulSet
 	Namenode      appsv1.StatefulSet
 	ConfigMaps    []corev1.ConfigMap
+	Secrets       []corev1.Secret
 	Services      []corev1.Service
+	PodDisruptionBudgets []policyv1.PodDisruptionBudget
 }
 
-func BuildExpectedResources(hdfs v1.HDFS) (HdfsResources, error) {
+// AllStatefulSets returns every StatefulSet of the resources
+func (r HdfsResources) AllStatefulSets() []appsv1.StatefulSet {
+	all := append(append([]appsv1.StatefulSet{}, r.StatefulSets...), r.Namenode, r.Datanode)
+	all = append(all, r.Zookeeper...)
+	return append(all, r.DatanodePools...)
+}
+
+func BuildExpectedResources(hdfs v1.HDFS, ext com.ExternalConfig) (HdfsResources, error) {
+
+	if err := com.ValidateHeapPercents(hdfs.Spec); err != nil {
+		return HdfsResources{}, err
+	}
+	if com.ZkQuorum(hdfs) == "" {
+		return HdfsResources{}, errors.New("either zkQuorum or zookeeper must be set")
+	}
 
-	VersionHandler(hdfs.Spec.Version)
+	configs, err := BuildConfigMaps(hdfs, ext)
+	if err != nil {
+		return HdfsResources{}, err
+	}
 
-	configs, err := BuildConfigMaps(hdfs)
+	secretConfig, err := com.BuildSecretConfig(hdfs, com.GetName(hdfs.Name, com.SecretConfigName), ext)
 	if err != nil {
 		return HdfsResources{}, err
 	}
@@ -39,6 +64,8 @@ func BuildExpectedResources(hdfs v1.HDFS) (HdfsResources, error) {
 		return HdfsResources{}, err
 	}
 
+	pdbs := BuildPodDisruptionBudgets(hdfs)
+
 	nnSet, err := nn.BuildStatefulSet(hdfs)
 	if err != nil {
 		return HdfsResources{}, err
@@ -49,31 +76,57 @@ func BuildExpectedResources(hdfs v1.HDFS) (HdfsResources, error) {
 		return HdfsResources{}, err
 	}
 
+	poolSets, err := dn.BuildPoolStatefulSets(hdfs)
+	if err != nil {
+		return HdfsResources{}, err
+	}
+
+	var zkSets []appsv1.StatefulSet
+	if com.EmbeddedZookeeper(hdfs) {
+		zkSet, err := zk.BuildStatefulSet(hdfs)
+		if err != nil {
+			return HdfsResources{}, err
+		}
+		zkSets = append(zkSets, zkSet)
+	}
+
+	secrets := []corev1.Secret{secretConfig}
+	if !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{}) {
+		yarnSecret, err := yarn.BuildSecret(hdfs, ext)
+		if err != nil {
+			return HdfsResources{}, err
+		}
+		secrets = append(secrets, yarnSecret)
+	}
+
 	return HdfsResources{
 		StatefulSets: statefulSets,
+		Zookeeper:    zkSets,
 		Namenode:     nnSet,
 		Datanode:     dnSet,
+		DatanodePools: poolSets,
 		ConfigMaps:   configs ,
+		Secrets:      secrets,
 		Services:     services,
+		PodDisruptionBudgets: pdbs,
 	}, nil
 }
 
-func VersionHandler(version string) {
-	if version[0:1] == "3" {
-		com.DatanodeRpcPort = 9864
-		com.NamenodeHttpPort = 9870
-		com.NamenodeRpcPort = 9820
-	}
-}
-
-func BuildConfigMaps(hdfs v1.HDFS) (c []corev1.ConfigMap,err error) {
+func BuildConfigMaps(hdfs v1.HDFS, ext com.ExternalConfig) (c []corev1.ConfigMap,err error) {
 
-	config, err := com.BuildHdfsConfig(hdfs, com.GetName(hdfs.Name, com.CommonConfigName))
+	config, err := com.BuildHdfsConfig(hdfs, com.GetName(hdfs.Name, com.CommonConfigName), ext)
 	if err != nil {
 		return c, err
 	}
 	nnScripts := nn.BuildConfigMap(hdfs)
-	dnScripts := dn.BuildConfigMap(hdfs)
+
+	if !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{}) {
+		yarnConfig, err := yarn.BuildConfigMap(hdfs, ext)
+		if err != nil {
+			return c, err
+		}
+		c = append(c, yarnConfig)
+	}
 
 	//if !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{}) {
 	//	yarnConfig ,err:= yarn.BuildConfigMap(hdfs)
@@ -83,14 +136,14 @@ func BuildConfigMaps(hdfs v1.HDFS) (c []corev1.ConfigMap,err error) {
 	//	c = append( c,yarnConfig)
 	//}
 
-	return append(c, config, nnScripts, dnScripts), nil
+	return append(c, config, nnScripts), nil
 }
 
 func BuildServices(hdfs v1.HDFS) (svc []corev1.Service,err error) {
 
 	nnSvc := com.HeadlessService(hdfs,
 		com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name),
-		nn.GetDefaultServicePorts())
+		nn.GetDefaultServicePorts(hdfs))
 	jnSvc := com.HeadlessService(hdfs, com.GetName(hdfs.Name,
 		hdfs.Spec.Journalnode.Name),
 		jn.GetDefaultServicePorts())
@@ -103,6 +156,16 @@ func BuildServices(hdfs v1.HDFS) (svc []corev1.Service,err error) {
 			com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-nm",
 			yarn.GetNMServicePorts())
 		svc = append(svc, rmSvc, nmSvc )
+		svc = append(svc, yarn.BuildServerServices(hdfs)...)
+	}
+	if com.EmbeddedZookeeper(hdfs) {
+		svc = append(svc, zk.BuildService(hdfs))
+	}
+	if com.PodNetwork(hdfs) {
+		// the datanodes register with the name of their pod
+		for _, pool := range dn.Pools(hdfs) {
+			svc = append(svc, com.HeadlessService(hdfs, com.GetName(hdfs.Name, pool.Name), dn.GetDefaultServicePorts(hdfs)))
+		}
 	}
 	return append(svc, nnSvc, jnSvc ), nil
 }
@@ -128,9 +191,40 @@ func BuildStatefulSets(hdfs v1.HDFS) (s []appsv1.StatefulSet,err error) {
 			return s, err
 		}
 		s = append(s,rmStatefulSet,nmStatefulSet)
+		servers, err := yarn.BuildServerStatefulSets(hdfs)
+		if err != nil {
+			return s, err
+		}
+		s = append(s, servers...)
 
 	}
 
 	//return append(s, nnStatefulSet, jnStatefulSet ), nil
 	return append(s, jnStatefulSet ), nil
-}
\ No newline at end of file
+}
+// BuildPodDisruptionBudgets keeps node drains from taking down both namenodes or the journalnode quorum
+func BuildPodDisruptionBudgets(hdfs v1.HDFS) []policyv1.PodDisruptionBudget {
+	pdbs := []policyv1.PodDisruptionBudget{
+		com.PodDisruptionBudget(hdfs, com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name), com.DefaultMaxUnavailable),
+		com.PodDisruptionBudget(hdfs, com.GetName(hdfs.Name, hdfs.Spec.Journalnode.Name),
+			com.QuorumMaxUnavailable(hdfs.Spec.Journalnode.Replicas)),
+	}
+	// each datanode pool is drained on its own
+	for _, pool := range dn.Pools(hdfs) {
+		dnMaxUnavailable := com.DefaultMaxUnavailable
+		if pool.MaxUnavailable != nil {
+			dnMaxUnavailable = *pool.MaxUnavailable
+		}
+		pdbs = append(pdbs, com.PodDisruptionBudget(hdfs, com.GetName(hdfs.Name, pool.Name), dnMaxUnavailable))
+	}
+	if com.EmbeddedZookeeper(hdfs) {
+		pdbs = append(pdbs, com.PodDisruptionBudget(hdfs, com.ZookeeperName(hdfs),
+			com.QuorumMaxUnavailable(hdfs.Spec.Zookeeper.Replicas)))
+	}
+	if !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{}) {
+		pdbs = append(pdbs,
+			com.PodDisruptionBudget(hdfs, com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-rm", com.DefaultMaxUnavailable),
+			com.PodDisruptionBudget(hdfs, com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-nm", com.DefaultMaxUnavailable))
+	}
+	return pdbs
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileServiceNames deletes the StatefulSets whose governing Service differs from the expected
+// one without their pods, as it cannot be updated, for them to be re-created with it. Datanode
+// StatefulSets created before the pod network mode had none, leaving the advertised pod names
+// unresolved. Pods created from then on get the new subdomain.
+func (d *DefaultDriver) reconcileServiceNames(ctx context.Context, expected []appsv1.StatefulSet) *Results {
+	results := &Results{}
+	for _, sset := range expected {
+		var actual appsv1.StatefulSet
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: sset.Namespace, Name: sset.Name}, &actual)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return results.WithError(err)
+		}
+		if !actual.DeletionTimestamp.IsZero() || actual.Spec.ServiceName == sset.Spec.ServiceName {
+			continue
+		}
+		log.Info("Deleting StatefulSet to re-create it with its governing Service",
+			"namespace", actual.Namespace, "name", actual.Name, "serviceName", sset.Spec.ServiceName)
+		orphan := client.PropagationPolicy("Orphan")
+		if err := d.Client.Delete(ctx, &actual, orphan); err != nil && !apierrors.IsNotFound(err) {
+			return results.WithError(err)
+		}
+		results.WithResult(defaultRequeue)
+	}
+	return results
+}
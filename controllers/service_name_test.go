@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileServiceNames(t *testing.T) {
+	unchanged := statefulSet("hdfs-namenode", 2)
+	unchanged.Spec.ServiceName = "hdfs-namenode"
+	legacy := statefulSet("hdfs-datanode", 3)
+	d := &DefaultDriver{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&unchanged, &legacy).Build()}
+
+	expected := []appsv1.StatefulSet{unchanged, statefulSet("hdfs-datanode", 3), statefulSet("hdfs-pool", 1)}
+	expected[1].Spec.ServiceName = "hdfs-datanode"
+	expected[2].Spec.ServiceName = "hdfs-pool"
+
+	res, err := d.reconcileServiceNames(context.Background(), expected).Aggregate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter == 0 {
+		t.Error("got no requeue, want the deleted StatefulSet re-created")
+	}
+	var sset appsv1.StatefulSet
+	if err := d.Client.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "hdfs-datanode"}, &sset); !apierrors.IsNotFound(err) {
+		t.Errorf("got %v, want the StatefulSet without Service deleted", err)
+	}
+	if err := d.Client.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "hdfs-namenode"}, &sset); err != nil {
+		t.Errorf("got %v, want the unchanged StatefulSet kept", err)
+	}
+}
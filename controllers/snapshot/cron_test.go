@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2021, 7, 30, 10, 17, 42, 0, time.UTC) // a friday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2021, 7, 30, 10, 18, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2021, 7, 30, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 7, 30, 10, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2021, 7, 30, 10, 25, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2021, 7, 31, 2, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 7, 31, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2021, 7, 30, 11, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 9,10 *", time.Date(2021, 10, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either day field matches when both are restricted
+		{"0 0 15 * 1", time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q): expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timeFormat is the UTC creation time part of the snapshot names, sortable as text
+const timeFormat = "20060102-150405"
+
+// Snapshot is a snapshot taken by a schedule
+type Snapshot struct {
+	Name    string
+	Created time.Time
+}
+
+// Name returns the name of the snapshot taken by a schedule at the given time
+func Name(prefix string, t time.Time) string {
+	return prefix + "-" + t.UTC().Format(timeFormat)
+}
+
+// Retention tells which snapshots of a schedule to keep, unset limits keep everything
+type Retention struct {
+	KeepLast *int32
+	MaxAge   *time.Duration
+}
+
+// Take makes the directory snapshottable if needed and creates the named snapshot.
+func Take(ctx context.Context, c *webhdfs.Client, dir, name string) error {
+	status, err := c.GetFileStatus(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if !status.SnapshotEnabled {
+		if err := c.AllowSnapshot(ctx, dir); err != nil {
+			return fmt.Errorf("allow snapshot: %w", err)
+		}
+	}
+	if _, err := c.CreateSnapshot(ctx, dir, name); err != nil {
+		return fmt.Errorf("create snapshot %s: %w", name, err)
+	}
+	return nil
+}
+
+// List returns the snapshots of the directory taken by the schedule with the given prefix,
+// oldest first. Other snapshots of the directory are ignored.
+func List(ctx context.Context, c *webhdfs.Client, dir, prefix string) ([]Snapshot, error) {
+	entries, err := c.ListStatus(ctx, path.Join(dir, ".snapshot"))
+	if webhdfs.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.PathSuffix, prefix+"-") {
+			continue
+		}
+		created, err := time.Parse(timeFormat, strings.TrimPrefix(entry.PathSuffix, prefix+"-"))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{Name: entry.PathSuffix, Created: created})
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// Expired returns the snapshots exceeding the retention, the latest snapshot is always kept.
+func Expired(snapshots []Snapshot, retention Retention, now time.Time) []Snapshot {
+	var expired []Snapshot
+	for i, s := range snapshots {
+		newer := len(snapshots) - 1 - i
+		if newer == 0 {
+			break
+		}
+		tooMany := retention.KeepLast != nil && newer >= int(*retention.KeepLast)
+		tooOld := retention.MaxAge != nil && now.Sub(s.Created) > *retention.MaxAge
+		if tooMany || tooOld {
+			expired = append(expired, s)
+		}
+	}
+	return expired
+}
+
+// Prune deletes the snapshots exceeding the retention and returns the ones left.
+func Prune(ctx context.Context, c *webhdfs.Client, dir string, snapshots []Snapshot, retention Retention, now time.Time) ([]Snapshot, error) {
+	deleted := map[string]bool{}
+	for _, s := range Expired(snapshots, retention, now) {
+		if err := c.DeleteSnapshot(ctx, dir, s.Name); err != nil && !webhdfs.IsNotFound(err) {
+			return nil, fmt.Errorf("delete snapshot %s: %w", s.Name, err)
+		}
+		deleted[s.Name] = true
+	}
+	var kept []Snapshot
+	for _, s := range snapshots {
+		if !deleted[s.Name] {
+			kept = append(kept, s)
+		}
+	}
+	return kept, nil
+}
+
+// Outcome is the result of a run of a schedule
+type Outcome struct {
+	// Taken is the name of the snapshot taken by the run, if any
+	Taken string
+	// Snapshots are the snapshots of the schedule kept after pruning
+	Snapshots []Snapshot
+	// Next is the next time a snapshot is due
+	Next time.Time
+}
+
+// Run takes the snapshot due since the last run of the schedule, if any, then prunes the snapshots
+// exceeding the retention. Missed runs are caught up with a single snapshot.
+func Run(ctx context.Context, c *webhdfs.Client, dir, prefix string, schedule *Schedule, retention Retention,
+	suspend bool, lastRun, now time.Time) (Outcome, error) {
+	outcome := Outcome{Next: schedule.Next(now)}
+
+	due := schedule.Next(lastRun)
+	if !suspend && !due.IsZero() && !due.After(now) {
+		outcome.Taken = Name(prefix, now)
+		if err := Take(ctx, c, dir, outcome.Taken); err != nil {
+			return Outcome{Next: outcome.Next}, err
+		}
+	}
+
+	snapshots, err := List(ctx, c, dir, prefix)
+	if err != nil {
+		return outcome, fmt.Errorf("list snapshots: %w", err)
+	}
+	if outcome.Snapshots, err = Prune(ctx, c, dir, snapshots, retention, now); err != nil {
+		return outcome, err
+	}
+	return outcome, nil
+}
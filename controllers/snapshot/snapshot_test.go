@@ -0,0 +1,225 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+)
+
+// fakeNamenode serves the WebHDFS operations used for snapshots on a single directory.
+type fakeNamenode struct {
+	mu            sync.Mutex
+	dir           string
+	snapshottable bool
+	snapshots     map[string]bool
+	ops           []string
+}
+
+func newFakeNamenode(dir string, snapshots ...string) *fakeNamenode {
+	nn := &fakeNamenode{dir: dir, snapshots: map[string]bool{}}
+	for _, s := range snapshots {
+		nn.snapshots[s] = true
+	}
+	return nn
+}
+
+func remoteException(w http.ResponseWriter, code int, exception, message string) {
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"RemoteException": map[string]string{"exception": exception, "message": message},
+	})
+}
+
+func (nn *fakeNamenode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nn.mu.Lock()
+	defer nn.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	op := r.URL.Query().Get("op")
+	name := r.URL.Query().Get("snapshotname")
+	nn.ops = append(nn.ops, op)
+
+	switch {
+	case op == "GETFILESTATUS" && path == nn.dir:
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"FileStatus": map[string]interface{}{"type": "DIRECTORY", "snapshotEnabled": nn.snapshottable},
+		})
+	case op == "ALLOWSNAPSHOT" && path == nn.dir:
+		nn.snapshottable = true
+	case op == "CREATESNAPSHOT" && path == nn.dir:
+		if !nn.snapshottable {
+			remoteException(w, http.StatusForbidden, "SnapshotException", "Directory is not a snapshottable directory: "+path)
+			return
+		}
+		nn.snapshots[name] = true
+		_ = json.NewEncoder(w).Encode(map[string]string{"Path": path + "/.snapshot/" + name})
+	case op == "DELETESNAPSHOT" && path == nn.dir:
+		delete(nn.snapshots, name)
+	case op == "LISTSTATUS" && path == nn.dir+"/.snapshot":
+		var entries []map[string]interface{}
+		for s := range nn.snapshots {
+			entries = append(entries, map[string]interface{}{"pathSuffix": s, "type": "DIRECTORY"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"FileStatuses": map[string]interface{}{"FileStatus": entries},
+		})
+	default:
+		remoteException(w, http.StatusNotFound, "FileNotFoundException", "File does not exist: "+path)
+	}
+}
+
+// standbyNamenode rejects every operation like a standby namenode.
+var standbyNamenode = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	remoteException(w, http.StatusForbidden, "StandbyException", "Operation category READ is not supported in state standby")
+})
+
+func newClient(t *testing.T, nn *fakeNamenode) *webhdfs.Client {
+	standby := httptest.NewServer(standbyNamenode)
+	active := httptest.NewServer(nn)
+	t.Cleanup(standby.Close)
+	t.Cleanup(active.Close)
+	return webhdfs.NewClient([]string{standby.URL, active.URL}, webhdfs.DefaultUser)
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func names(snapshots []Snapshot) []string {
+	var n []string
+	for _, s := range snapshots {
+		n = append(n, s.Name)
+	}
+	return n
+}
+
+func TestRunTakesDueSnapshot(t *testing.T) {
+	nn := newFakeNamenode("/warehouse")
+	c := newClient(t, nn)
+	schedule, _ := ParseSchedule("0 * * * *")
+	lastRun := time.Date(2021, 7, 30, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 7, 30, 10, 0, 30, 0, time.UTC)
+
+	outcome, err := Run(context.Background(), c, "/warehouse", "hourly", schedule, Retention{}, false, lastRun, now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if outcome.Taken != "hourly-20210730-100030" {
+		t.Errorf("Taken = %q", outcome.Taken)
+	}
+	if !nn.snapshottable {
+		t.Errorf("directory was not made snapshottable")
+	}
+	if got := names(outcome.Snapshots); !reflect.DeepEqual(got, []string{"hourly-20210730-100030"}) {
+		t.Errorf("Snapshots = %v", got)
+	}
+	if want := time.Date(2021, 7, 30, 11, 0, 0, 0, time.UTC); !outcome.Next.Equal(want) {
+		t.Errorf("Next = %v, want %v", outcome.Next, want)
+	}
+}
+
+func TestRunWaitsForSchedule(t *testing.T) {
+	nn := newFakeNamenode("/warehouse")
+	c := newClient(t, nn)
+	schedule, _ := ParseSchedule("0 * * * *")
+	lastRun := time.Date(2021, 7, 30, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 7, 30, 10, 30, 0, 0, time.UTC)
+
+	outcome, err := Run(context.Background(), c, "/warehouse", "hourly", schedule, Retention{}, false, lastRun, now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if outcome.Taken != "" || len(nn.snapshots) != 0 {
+		t.Errorf("unexpected snapshot %q", outcome.Taken)
+	}
+
+	// suspended schedules take nothing either
+	now = time.Date(2021, 7, 30, 12, 0, 0, 0, time.UTC)
+	if outcome, err = Run(context.Background(), c, "/warehouse", "hourly", schedule, Retention{}, true, lastRun, now); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if outcome.Taken != "" || len(nn.snapshots) != 0 {
+		t.Errorf("unexpected snapshot %q while suspended", outcome.Taken)
+	}
+}
+
+func TestRunPrunesBeyondRetention(t *testing.T) {
+	nn := newFakeNamenode("/warehouse",
+		"hourly-20210730-060000",
+		"hourly-20210730-070000",
+		"hourly-20210730-080000",
+		"hourly-20210730-090000",
+		"manual-backup",
+		"daily-20210701-000000",
+	)
+	nn.snapshottable = true
+	c := newClient(t, nn)
+	schedule, _ := ParseSchedule("0 * * * *")
+	lastRun := time.Date(2021, 7, 30, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 7, 30, 10, 0, 0, 0, time.UTC)
+
+	outcome, err := Run(context.Background(), c, "/warehouse", "hourly", schedule,
+		Retention{KeepLast: int32Ptr(3)}, false, lastRun, now)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []string{"hourly-20210730-080000", "hourly-20210730-090000", "hourly-20210730-100000"}
+	if got := names(outcome.Snapshots); !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshots = %v, want %v", got, want)
+	}
+	// snapshots of other schedules and manual ones are left alone
+	for _, s := range []string{"manual-backup", "daily-20210701-000000"} {
+		if !nn.snapshots[s] {
+			t.Errorf("snapshot %s was deleted", s)
+		}
+	}
+	for _, s := range []string{"hourly-20210730-060000", "hourly-20210730-070000"} {
+		if nn.snapshots[s] {
+			t.Errorf("snapshot %s was not deleted", s)
+		}
+	}
+}
+
+func TestExpiredByAge(t *testing.T) {
+	now := time.Date(2021, 7, 30, 10, 0, 0, 0, time.UTC)
+	snapshots := []Snapshot{
+		{Name: "a", Created: now.Add(-72 * time.Hour)},
+		{Name: "b", Created: now.Add(-25 * time.Hour)},
+		{Name: "c", Created: now.Add(-time.Hour)},
+	}
+	if got := names(Expired(snapshots, Retention{MaxAge: durationPtr(24 * time.Hour)}, now)); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expired = %v", got)
+	}
+	// the latest snapshot is kept whatever its age
+	if got := names(Expired(snapshots, Retention{MaxAge: durationPtr(time.Minute)}, now)); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Expired = %v", got)
+	}
+	if got := Expired(snapshots, Retention{}, now); len(got) != 0 {
+		t.Errorf("Expired = %v, want none without retention", names(got))
+	}
+}
+
+func TestRunReportsFailure(t *testing.T) {
+	nn := newFakeNamenode("/warehouse")
+	c := newClient(t, nn)
+	schedule, _ := ParseSchedule("@hourly")
+	lastRun := time.Date(2021, 7, 30, 9, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 7, 30, 10, 0, 0, 0, time.UTC)
+
+	_, err := Run(context.Background(), c, "/missing", "hourly", schedule, Retention{}, false, lastRun, now)
+	if !webhdfs.IsNotFound(err) {
+		t.Errorf("Run on a missing directory: got %v, want FileNotFoundException", err)
+	}
+}
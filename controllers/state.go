@@ -3,10 +3,16 @@ package controllers
 import (
 	"context"
 	"github.com/dataworkbench/hdfs-operator/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8serrors "k8s.io/apimachinery/pkg/util/errors"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
 )
 
+var defaultRequeue = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+
 // State holds the accumulated state during the reconcile loop including the response and a pointer to an HDFS resource for status updates.
 type State struct {
 	//*events.Recorder
@@ -19,6 +25,83 @@ func NewState(c v1.HDFS) *State {
 	return &State{cluster: c, status: *c.Status.DeepCopy()}
 }
 
+// UpdateVolumeExpansions records the claims whose expansion is in progress.
+func (s *State) UpdateVolumeExpansions(expansions []v1.VolumeExpansion) *State {
+	s.status.VolumeExpansions = expansions
+	return s
+}
+
+// UpdateDatanodePools records the observed state of the datanode pools.
+func (s *State) UpdateDatanodePools(pools []v1.DatanodePoolStatus) *State {
+	s.status.DatanodePools = pools
+	return s
+}
+
+// UpdateZkfc records the health of the failover controllers.
+func (s *State) UpdateZkfc(zkfc []v1.ZkfcStatus) *State {
+	s.status.Zkfc = zkfc
+	return s
+}
+
+// UpdateClientConfig records the name of the client configuration of the cluster.
+func (s *State) UpdateClientConfig(name string) *State {
+	s.status.ClientConfig = name
+	return s
+}
+
+// UpdateExternalClientConfig records the name of the client configuration of the external addresses.
+func (s *State) UpdateExternalClientConfig(name string) *State {
+	s.status.ExternalClientConfig = name
+	return s
+}
+
+// UpdatePaths records the outcome of applying spec.paths.
+func (s *State) UpdatePaths(paths []v1.PathStatus) *State {
+	s.status.Paths = paths
+	return s
+}
+
+// SetCondition adds or updates a status condition, for the current generation of the cluster.
+func (s *State) SetCondition(conditionType string, status metav1.ConditionStatus, reason, message string) *State {
+	meta.SetStatusCondition(&s.status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: s.cluster.Generation,
+	})
+	return s
+}
+
+// UpdateClusterIdentity records the identity of the namespace served by the namenodes.
+func (s *State) UpdateClusterIdentity(clusterID, blockPoolID string) *State {
+	s.status.ClusterID = clusterID
+	s.status.BlockPoolID = blockPoolID
+	return s
+}
+
+// UpdateBackup records the last runs of the backup job.
+func (s *State) UpdateBackup(backup *v1.BackupStatus) *State {
+	s.status.Backup = backup
+	return s
+}
+
+// RemoveCondition removes the status condition of the given type, if any.
+func (s *State) RemoveCondition(conditionType string) *State {
+	meta.RemoveStatusCondition(&s.status.Conditions, conditionType)
+	return s
+}
+
+// Apply returns the cluster with the accumulated status, or nil if the status did not change.
+func (s *State) Apply() *v1.HDFS {
+	if reflect.DeepEqual(s.cluster.Status, s.status) {
+		return nil
+	}
+	cluster := s.cluster.DeepCopy()
+	cluster.Status = s.status
+	return cluster
+}
+
 // Results collects intermediate results of a reconciliation run and any errors that occurred.
 type Results struct {
 	currResult reconcile.Result //controller-runtime  type Result struct { Requeue  RequeueAfter }
@@ -38,6 +121,24 @@ func (r *Results) WithError(err error) *Results {
 	return r
 }
 
+// WithResult merges the given result, keeping the earliest requeue.
+func (r *Results) WithResult(res reconcile.Result) *Results {
+	if res.IsZero() {
+		return r
+	}
+	if r.currResult.IsZero() ||
+		(res.RequeueAfter > 0 && (r.currResult.RequeueAfter == 0 || res.RequeueAfter < r.currResult.RequeueAfter)) {
+		r.currResult = res
+	}
+	return r
+}
+
+// WithResults appends the results and errors from other Results.
+func (r *Results) WithResults(other *Results) *Results {
+	r.errors = append(r.errors, other.errors...)
+	return r.WithResult(other.currResult)
+}
+
 // Aggregate returns the highest priority reconcile result and any errors seen so far.
 func (r *Results) Aggregate() (reconcile.Result, error) {
 	return r.currResult, k8serrors.NewAggregate(r.errors)
@@ -0,0 +1,108 @@
+package topology
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
+)
+
+const (
+	TopologyConfigName = "topology"
+	MappingKey         = "topology.data"
+
+	TopologyVolumeName = "topology"
+
+	DefaultNodeLabel = "topology.kubernetes.io/zone"
+	DefaultRack      = "/default-rack"
+)
+
+// script prints the rack of every host name or ip given as argument, as read from the mapping
+// file kept up to date by the operator. Unknown hosts go to the default rack.
+var script = `#!/usr/bin/env bash
+     _MAPPING=` + com.TopologyConfigMountPath + "/" + MappingKey + `
+     for _HOST in "$@"; do
+       _RACK=$(awk -v host="$_HOST" '$1 == host { print $2; exit }' $_MAPPING 2>/dev/null)
+       echo -n "${_RACK:-` + DefaultRack + `} "
+     done
+     echo`
+
+// NodeLabel returns the node label giving the first level of the rack
+func NodeLabel(rack *v1.RackAwareness) string {
+	if rack == nil || rack.NodeLabel == "" {
+		return DefaultNodeLabel
+	}
+	return rack.NodeLabel
+}
+
+// Rack returns the rack of a datanode running on the given node: /<node label value>/<node name>
+func Rack(node corev1.Node, nodeLabel string) string {
+	zone := node.Labels[nodeLabel]
+	if zone == "" {
+		zone = strings.TrimPrefix(DefaultRack, "/")
+	}
+	return "/" + zone + "/" + node.Name
+}
+
+// BuildMapping renders the host to rack mapping of the scheduled datanode pods. Pods are
+// reachable by ip, pod name and node name, which are the same with host networking.
+func BuildMapping(pods []corev1.Pod, nodes map[string]corev1.Node, nodeLabel string) string {
+	racks := map[string]string{}
+	for _, pod := range pods {
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		rack := Rack(node, nodeLabel)
+		for _, host := range []string{pod.Status.PodIP, pod.Status.HostIP, pod.Name, node.Name} {
+			if host != "" {
+				racks[host] = rack
+			}
+		}
+	}
+	hosts := make([]string, 0, len(racks))
+	for host := range racks {
+		hosts = append(hosts, host)
+	}
+	// sort for a stable config map content
+	sort.Strings(hosts)
+	var mapping strings.Builder
+	for _, host := range hosts {
+		mapping.WriteString(host + " " + racks[host] + "\n")
+	}
+	return mapping.String()
+}
+
+// BuildConfigMap builds the config map holding the topology script and the mapping of the datanodes
+func BuildConfigMap(hdfs v1.HDFS, pods []corev1.Pod, nodes map[string]corev1.Node) corev1.ConfigMap {
+
+	configmap := types.NamespacedName{Namespace: hdfs.Namespace, Name: com.GetName(hdfs.Name, TopologyConfigName)}
+
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            configmap.Name,
+			Namespace:       configmap.Namespace,
+			Labels:          com.NewLabels(configmap),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Data: map[string]string{
+			com.TopologyScriptFileName: script,
+			MappingKey:                 BuildMapping(pods, nodes, NodeLabel(hdfs.Spec.RackAwareness)),
+		},
+	}
+}
+
+// BuildVolume returns the volume exposing the topology config map to the namenodes
+func BuildVolume(hdfs v1.HDFS) com.ConfigMapVolume {
+	return com.NewConfigMapVolumeWithMode(com.GetName(hdfs.Name, TopologyConfigName),
+		TopologyVolumeName,
+		com.TopologyConfigMountPath,
+		0755)
+}
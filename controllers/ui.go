@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/external"
+	"github.com/dataworkbench/hdfs-operator/controllers/ui"
+	"github.com/dataworkbench/hdfs-operator/controllers/webhdfs"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileUI routes the web UI hosts to the active namenode and to the ResourceManager. The
+// namenode pods are labelled with their HA state for the Service of the active one to follow
+// failovers, which are caught up with on the next requeue.
+func (d *DefaultDriver) reconcileUI(ctx context.Context) *Results {
+	results := &Results{}
+	if d.Hdfs.Spec.UI == nil {
+		return results.WithError(d.deleteUI(ctx))
+	}
+	ready, err := namenodesReady(ctx, d)
+	if err != nil {
+		return results.WithError(err)
+	}
+	if ready {
+		podNames := external.PodNames(com.GetName(d.Hdfs.Name, d.Hdfs.Spec.Namenode.Name), d.Hdfs.Spec.Namenode.Replicas)
+		if err := d.labelHAStates(ctx, podNames, webhdfs.ForCluster(d.Hdfs).GetHAStates(ctx)); err != nil {
+			return results.WithError(err)
+		}
+	}
+	if _, err := ReconcileService(d.Client, ptrService(ui.BuildActiveNamenodeService(d.Hdfs, int32(com.NamenodeHttpPort(d.Hdfs.Spec.Version)))), &d.Hdfs); err != nil {
+		return results.WithError(err)
+	}
+
+	backends := ui.Backends(d.Hdfs, int32(com.NamenodeHttpPort(d.Hdfs.Spec.Version)))
+	for _, backend := range backends {
+		if d.Hdfs.Spec.UI.Type == v1.UIRouteHTTPRoute {
+			err = d.reconcileHTTPRoute(ctx, backend)
+		} else {
+			_, err = ReconcileIngress(d.Client, ui.BuildIngress(d.Hdfs, backend), &d.Hdfs)
+		}
+		if err != nil {
+			return results.WithError(err)
+		}
+	}
+	if err := d.deleteStaleRoutes(ctx, backends); err != nil {
+		return results.WithError(err)
+	}
+	return results.WithResult(defaultRequeue)
+}
+
+// reconcileHTTPRoute creates or updates the HTTPRoute of a web UI
+func (d *DefaultDriver) reconcileHTTPRoute(ctx context.Context, backend ui.Backend) error {
+	if d.Hdfs.Spec.UI.Gateway == nil {
+		return fmt.Errorf("ui: a gateway is required by HTTPRoutes")
+	}
+	expected := ui.BuildHTTPRoute(d.Hdfs, backend)
+	reconciled := &unstructured.Unstructured{}
+	reconciled.SetGroupVersionKind(ui.HTTPRouteGVK)
+	return ReconcileResource(Params{
+		Client:     d.Client,
+		Owner:      &d.Hdfs,
+		Expected:   expected,
+		Reconciled: reconciled,
+		NeedsUpdate: func() bool {
+			return reconciled.GetAnnotations()[com.SpecHashAnnotation] != expected.GetAnnotations()[com.SpecHashAnnotation]
+		},
+		UpdateReconciled: func() {
+			annotations := reconciled.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[com.SpecHashAnnotation] = expected.GetAnnotations()[com.SpecHashAnnotation]
+			reconciled.SetAnnotations(annotations)
+			reconciled.Object["spec"] = expected.Object["spec"]
+		},
+	})
+}
+
+// ReconcileIngress creates or updates the Ingress kind
+func ReconcileIngress(c client.Client, expected networkingv1.Ingress, owner client.Object) (networkingv1.Ingress, error) {
+	var reconciled networkingv1.Ingress
+	err := ReconcileResource(Params{
+		Client:     c,
+		Owner:      owner,
+		Expected:   &expected,
+		Reconciled: &reconciled,
+		NeedsUpdate: func() bool {
+			return reconciled.Annotations[com.SpecHashAnnotation] != expected.Annotations[com.SpecHashAnnotation]
+		},
+		UpdateReconciled: func() {
+			reconciled.Annotations = expected.Annotations
+			reconciled.Spec = expected.Spec
+		},
+	})
+	return reconciled, err
+}
+
+// deleteStaleRoutes removes the Ingresses and HTTPRoutes of the UIs no longer exposed, or exposed
+// by the other kind
+func (d *DefaultDriver) deleteStaleRoutes(ctx context.Context, backends []ui.Backend) error {
+	expected := map[string]bool{}
+	for _, backend := range backends {
+		expected[backend.Name] = true
+	}
+	useRoutes := d.Hdfs.Spec.UI != nil && d.Hdfs.Spec.UI.Type == v1.UIRouteHTTPRoute
+	labels := client.MatchingLabels(com.NewLabels(com.ExtractNamespacedName(&d.Hdfs)))
+
+	var ingresses networkingv1.IngressList
+	if err := d.Client.List(ctx, &ingresses, client.InNamespace(d.Hdfs.Namespace), labels); err != nil {
+		return err
+	}
+	for i := range ingresses.Items {
+		if expected[ingresses.Items[i].Name] && !useRoutes {
+			continue
+		}
+		if err := d.Client.Delete(ctx, &ingresses.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	routes := &unstructured.UnstructuredList{}
+	routes.SetGroupVersionKind(ui.HTTPRouteGVK.GroupVersion().WithKind(ui.HTTPRouteGVK.Kind + "List"))
+	err := d.Client.List(ctx, routes, client.InNamespace(d.Hdfs.Namespace), labels)
+	if meta.IsNoMatchError(err) {
+		// the Gateway API is not installed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := range routes.Items {
+		if expected[routes.Items[i].GetName()] && useRoutes {
+			continue
+		}
+		if err := d.Client.Delete(ctx, &routes.Items[i]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUI removes the routes and the Service of the active namenode
+func (d *DefaultDriver) deleteUI(ctx context.Context) error {
+	if err := d.deleteStaleRoutes(ctx, nil); err != nil {
+		return err
+	}
+	var svc corev1.Service
+	svc.Namespace, svc.Name = d.Hdfs.Namespace, ui.ActiveNamenodeServiceName(d.Hdfs)
+	if err := d.Client.Delete(ctx, &svc); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+)
+
+const (
+	ActiveNamenodeServiceSuffix = "-active"
+)
+
+// HTTPRouteGVK is the kind of the Gateway API HTTPRoutes
+var HTTPRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+// Backend is a web UI, reached through the given Service
+type Backend struct {
+	// Name of the Ingress or HTTPRoute
+	Name        string
+	Host        string
+	ServiceName string
+	Port        int32
+}
+
+// Backends returns the web UIs of the cluster given a host, the YARN ones if run
+func Backends(hdfs v1.HDFS, namenodeHttpPort int32) []Backend {
+	ui := hdfs.Spec.UI
+	var backends []Backend
+	if ui.NamenodeHost != "" {
+		backends = append(backends, Backend{
+			Name:        com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name) + "-ui",
+			Host:        ui.NamenodeHost,
+			ServiceName: ActiveNamenodeServiceName(hdfs),
+			Port:        namenodeHttpPort,
+		})
+	}
+	if ui.ResourceManagerHost != "" && !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{}) {
+		backends = append(backends, Backend{
+			Name:        com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name) + "-rm-ui",
+			Host:        ui.ResourceManagerHost,
+			ServiceName: yarn.ActiveRMServiceName(hdfs),
+			Port:        yarn.RMWebPort,
+		})
+	}
+	if ui.JobHistoryHost != "" && hdfs.Spec.Yarn.HistoryServer != nil {
+		backends = append(backends, Backend{
+			Name:        com.YarnServerName(hdfs, com.JobHistorySuffix) + "-ui",
+			Host:        ui.JobHistoryHost,
+			ServiceName: com.YarnServerName(hdfs, com.JobHistorySuffix),
+			Port:        com.JobHistoryWebPort,
+		})
+	}
+	return backends
+}
+
+// ActiveNamenodeServiceName returns the name of the Service of the active namenode
+func ActiveNamenodeServiceName(hdfs v1.HDFS) string {
+	return com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name) + ActiveNamenodeServiceSuffix
+}
+
+// BuildActiveNamenodeService builds the Service selecting the namenode pod labelled active
+func BuildActiveNamenodeService(hdfs v1.HDFS, httpPort int32) corev1.Service {
+	ssetName := com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name)
+	selector := com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), ssetName)
+	selector[com.HAStateLabelName] = com.HAStateActive
+	return corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            ActiveNamenodeServiceName(hdfs),
+			Labels:          com.NewLabels(com.ExtractNamespacedName(&hdfs)),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: selector,
+			Ports:    []corev1.ServicePort{{Name: "http", Port: httpPort, TargetPort: intstr.FromInt(int(httpPort))}},
+		},
+	}
+}
+
+// BuildIngress builds the Ingress of a web UI
+func BuildIngress(hdfs v1.HDFS, backend Backend) networkingv1.Ingress {
+	ui := hdfs.Spec.UI
+	pathType := networkingv1.PathTypePrefix
+	ingress := networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Ingress",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            backend.Name,
+			Labels:          com.NewLabels(com.ExtractNamespacedName(&hdfs)),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ui.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: backend.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: backend.ServiceName,
+							Port: networkingv1.ServiceBackendPort{Number: backend.Port},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if ui.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{backend.Host}, SecretName: ui.TLSSecretName}}
+	}
+	ingress.Annotations = map[string]string{}
+	for k, v := range ui.Annotations {
+		ingress.Annotations[k] = v
+	}
+	ingress.Annotations[com.SpecHashAnnotation] = com.SpecHash([]interface{}{ingress.Spec, ui.Annotations})
+	return ingress
+}
+
+// BuildHTTPRoute builds the HTTPRoute of a web UI, the Gateway API types not being vendored
+func BuildHTTPRoute(hdfs v1.HDFS, backend Backend) *unstructured.Unstructured {
+	gateway := hdfs.Spec.UI.Gateway
+	parentRef := map[string]interface{}{"name": gateway.Name}
+	if gateway.Namespace != "" {
+		parentRef["namespace"] = gateway.Namespace
+	}
+	if gateway.SectionName != "" {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{backend.Host},
+		"rules": []interface{}{map[string]interface{}{
+			"backendRefs": []interface{}{map[string]interface{}{
+				"name": backend.ServiceName,
+				"port": int64(backend.Port),
+			}},
+		}},
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetNamespace(hdfs.Namespace)
+	route.SetName(backend.Name)
+	route.SetLabels(com.NewLabels(com.ExtractNamespacedName(&hdfs)))
+	route.SetOwnerReferences(com.GetOwnerReference(hdfs))
+	route.SetAnnotations(map[string]string{com.SpecHashAnnotation: com.SpecHash(spec)})
+	route.Object["spec"] = spec
+	return route
+}
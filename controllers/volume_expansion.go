@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// defaultStorageClassAnnotation marks the class of the claims without one
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// reconcileVolumeExpansion resizes the existing claims of the StatefulSets whose expected claim
+// templates ask for more storage. As claim templates of a StatefulSet cannot be updated, the
+// StatefulSet is then deleted without its pods to be re-created with the new templates.
+func (d *DefaultDriver) reconcileVolumeExpansion(ctx context.Context, expected []appsv1.StatefulSet) *Results {
+	results := &Results{}
+	var expansions []v1.VolumeExpansion
+
+	for _, sset := range expected {
+		var actual appsv1.StatefulSet
+		err := d.Client.Get(ctx, types.NamespacedName{Namespace: sset.Namespace, Name: sset.Name}, &actual)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return results.WithError(err)
+		}
+		if !actual.DeletionTimestamp.IsZero() {
+			// orphan deletion in progress, re-create it once gone
+			results.WithResult(defaultRequeue)
+			continue
+		}
+
+		grown := false
+		for _, claim := range sset.Spec.VolumeClaimTemplates {
+			actualClaim, ok := claimTemplate(actual, claim.Name)
+			if !ok {
+				continue
+			}
+			want := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+			have := actualClaim.Spec.Resources.Requests[corev1.ResourceStorage]
+			switch want.Cmp(have) {
+			case -1:
+				results.WithError(fmt.Errorf("claim %s of %s: decreasing capacity from %s to %s is not supported",
+					claim.Name, sset.Name, have.String(), want.String()))
+				continue
+			case 0:
+				continue
+			}
+
+			pvcs, err := d.claimsOf(ctx, actual, claim.Name)
+			if err != nil {
+				return results.WithError(err)
+			}
+			className, expandable, err := allowsExpansion(ctx, d.Client, claim, pvcs)
+			if err != nil {
+				return results.WithError(err)
+			}
+			if !expandable {
+				expansions = append(expansions, v1.VolumeExpansion{
+					ClaimName: claim.Name + "-" + sset.Name,
+					Requested: want.String(),
+					Capacity:  have.String(),
+					Phase:     v1.VolumeExpansionUnsupported,
+					Message:   fmt.Sprintf("storage class %s does not allow volume expansion", className),
+				})
+				continue
+			}
+			for i := range pvcs {
+				pvc := pvcs[i]
+				current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+				if current.Cmp(want) >= 0 {
+					continue
+				}
+				log.Info("Expanding volume claim", "namespace", pvc.Namespace, "name", pvc.Name,
+					"from", current.String(), "to", want.String())
+				pvc.Spec.Resources.Requests[corev1.ResourceStorage] = want
+				if err := d.Client.Update(ctx, &pvc); err != nil {
+					return results.WithError(err)
+				}
+			}
+			grown = true
+		}
+
+		if grown {
+			log.Info("Deleting StatefulSet to re-create it with expanded claim templates",
+				"namespace", actual.Namespace, "name", actual.Name)
+			orphan := client.PropagationPolicy("Orphan")
+			if err := d.Client.Delete(ctx, &actual, orphan); err != nil && !apierrors.IsNotFound(err) {
+				return results.WithError(err)
+			}
+			results.WithResult(defaultRequeue)
+		}
+
+		inProgress, err := d.expansionsInProgress(ctx, actual, sset)
+		if err != nil {
+			return results.WithError(err)
+		}
+		if len(inProgress) > 0 {
+			// keep tracking the resize progress
+			results.WithResult(defaultRequeue)
+		}
+		expansions = append(expansions, inProgress...)
+	}
+
+	if d.ReconcileState != nil {
+		d.ReconcileState.UpdateVolumeExpansions(expansions)
+	}
+	return results
+}
+
+// expansionsInProgress reports the claims of the StatefulSet whose provisioned capacity is below the request.
+func (d *DefaultDriver) expansionsInProgress(ctx context.Context, actual appsv1.StatefulSet, expected appsv1.StatefulSet) ([]v1.VolumeExpansion, error) {
+	var expansions []v1.VolumeExpansion
+	for _, claim := range expected.Spec.VolumeClaimTemplates {
+		pvcs, err := d.claimsOf(ctx, actual, claim.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, pvc := range pvcs {
+			requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+			if !ok || capacity.Cmp(requested) >= 0 {
+				continue
+			}
+			expansion := v1.VolumeExpansion{
+				ClaimName: pvc.Name,
+				Requested: requested.String(),
+				Capacity:  capacity.String(),
+				Phase:     v1.VolumeResizing,
+			}
+			for _, c := range pvc.Status.Conditions {
+				if c.Type == corev1.PersistentVolumeClaimFileSystemResizePending && c.Status == corev1.ConditionTrue {
+					expansion.Phase = v1.VolumeFileSystemResizePending
+					expansion.Message = c.Message
+				}
+			}
+			expansions = append(expansions, expansion)
+		}
+	}
+	return expansions, nil
+}
+
+// claimsOf returns the existing claims created from the given claim template of the StatefulSet,
+// named <claim>-<statefulset>-<ordinal>.
+func (d *DefaultDriver) claimsOf(ctx context.Context, sset appsv1.StatefulSet, claimName string) ([]corev1.PersistentVolumeClaim, error) {
+	var list corev1.PersistentVolumeClaimList
+	opts := []client.ListOption{client.InNamespace(sset.Namespace)}
+	if sset.Spec.Selector != nil {
+		opts = append(opts, client.MatchingLabels(sset.Spec.Selector.MatchLabels))
+	}
+	if err := d.Client.List(ctx, &list, opts...); err != nil {
+		return nil, err
+	}
+	prefix := claimName + "-" + sset.Name + "-"
+	var pvcs []corev1.PersistentVolumeClaim
+	for _, pvc := range list.Items {
+		if strings.HasPrefix(pvc.Name, prefix) {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+// allowsExpansion returns the storage class of the claims created from the template, and true if
+// it allows volume expansion. The class is the one admission set on the existing claims, the
+// default class for a template without one.
+func allowsExpansion(ctx context.Context, c client.Client, template corev1.PersistentVolumeClaim,
+	pvcs []corev1.PersistentVolumeClaim) (string, bool, error) {
+	name := template.Spec.StorageClassName
+	for _, pvc := range pvcs {
+		if pvc.Spec.StorageClassName != nil {
+			name = pvc.Spec.StorageClassName
+			break
+		}
+	}
+
+	var sc storagev1.StorageClass
+	if name == nil {
+		var classes storagev1.StorageClassList
+		if err := c.List(ctx, &classes); err != nil {
+			return "", false, err
+		}
+		found := false
+		for _, class := range classes.Items {
+			if class.Annotations[defaultStorageClassAnnotation] == "true" {
+				sc, found = class, true
+				break
+			}
+		}
+		if !found {
+			return "<default>", false, nil
+		}
+	} else if *name == "" {
+		// bound to volumes without class
+		return `""`, false, nil
+	} else {
+		err := c.Get(ctx, types.NamespacedName{Name: *name}, &sc)
+		if apierrors.IsNotFound(err) {
+			return *name, false, nil
+		} else if err != nil {
+			return *name, false, err
+		}
+	}
+	return sc.Name, sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+func claimTemplate(sset appsv1.StatefulSet, name string) (corev1.PersistentVolumeClaim, bool) {
+	for _, claim := range sset.Spec.VolumeClaimTemplates {
+		if claim.Name == name {
+			return claim, true
+		}
+	}
+	return corev1.PersistentVolumeClaim{}, false
+}
+
+
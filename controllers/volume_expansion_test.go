@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func storageClass(name string, expandable, isDefault bool) *storagev1.StorageClass {
+	sc := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: name},
+		Provisioner:          "example.com/csi",
+		AllowVolumeExpansion: &expandable,
+	}
+	if isDefault {
+		sc.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+	}
+	return sc
+}
+
+func claim(className *string) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: className}}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestAllowsExpansion(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		storageClass("fast", true, false),
+		storageClass("slow", false, false),
+		storageClass("standard", true, true),
+	).Build()
+
+	for _, tt := range []struct {
+		name       string
+		template   corev1.PersistentVolumeClaim
+		pvcs       []corev1.PersistentVolumeClaim
+		class      string
+		expandable bool
+	}{
+		{"expandable class", claim(stringPtr("fast")), nil, "fast", true},
+		{"non expandable class", claim(stringPtr("slow")), nil, "slow", false},
+		{"missing class", claim(stringPtr("gone")), nil, "gone", false},
+		{"default class of the template", claim(nil), nil, "standard", true},
+		{"class set by admission on the claims", claim(nil), []corev1.PersistentVolumeClaim{claim(stringPtr("slow"))}, "slow", false},
+		{"volumes without class", claim(stringPtr("")), nil, `""`, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			class, expandable, err := allowsExpansion(context.Background(), c, tt.template, tt.pvcs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if class != tt.class || expandable != tt.expandable {
+				t.Errorf("expected %s %v, got %s %v", tt.class, tt.expandable, class, expandable)
+			}
+		})
+	}
+}
+
+func TestAllowsExpansionWithoutDefaultClass(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(storageClass("fast", true, false)).Build()
+	class, expandable, err := allowsExpansion(context.Background(), c, claim(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expandable {
+		t.Errorf("expected %s not to be expandable", class)
+	}
+}
@@ -0,0 +1,420 @@
+package webhdfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultUser is the user the operator acts as on the host network, the HDFS daemons of the
+	// image run as root which makes it the HDFS superuser.
+	DefaultUser = com.HostNetworkHadoopUser
+
+	defaultTimeout = 30 * time.Second
+)
+
+// RemoteException is the error returned by the namenode for a failed operation
+type RemoteException struct {
+	StatusCode    int    `json:"-"`
+	Exception     string `json:"exception"`
+	JavaClassName string `json:"javaClassName"`
+	Message       string `json:"message"`
+}
+
+func (e *RemoteException) Error() string {
+	return fmt.Sprintf("%s: %s", e.Exception, e.Message)
+}
+
+// IsNotFound returns true if the error reports a missing file or directory
+func IsNotFound(err error) bool {
+	var remote *RemoteException
+	return errors.As(err, &remote) && remote.Exception == "FileNotFoundException"
+}
+
+// isStandby returns true if the error comes from a standby namenode, which redirects nobody
+// to the active one.
+func isStandby(err error) bool {
+	remote, ok := err.(*RemoteException)
+	return ok && remote.Exception == "StandbyException"
+}
+
+// Client calls the WebHDFS REST API of the namenodes of a cluster, trying each
+// namenode in turn until the active one answers.
+type Client struct {
+	Endpoints  []string
+	User       string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the given namenode http endpoints, e.g. http://nn-0:9870
+func NewClient(endpoints []string, user string) *Client {
+	return &Client{
+		Endpoints:  endpoints,
+		User:       user,
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// NamenodeEndpoints returns the http endpoints of the namenodes of the cluster
+func NamenodeEndpoints(hdfs v1.HDFS) []string {
+	nnPrefix := com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name)
+	nnService := nnPrefix + "." + hdfs.Namespace + ".svc.cluster.local"
+	var endpoints []string
+	for i := 0; i < int(hdfs.Spec.Namenode.Replicas); i++ {
+		endpoints = append(endpoints, fmt.Sprintf("http://%s-%d.%s:%d", nnPrefix, i, nnService, com.NamenodeHttpPort(hdfs.Spec.Version)))
+	}
+	return endpoints
+}
+
+// ForCluster returns a client for the namenodes of the cluster, acting as its Hadoop user
+func ForCluster(hdfs v1.HDFS) *Client {
+	return NewClient(NamenodeEndpoints(hdfs), com.HadoopUser(hdfs))
+}
+
+// do runs the operation on the first namenode accepting it and decodes the json answer into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path, op string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("op", op)
+	params.Set("user.name", c.User)
+
+	err := fmt.Errorf("no namenode endpoint")
+	for _, endpoint := range c.Endpoints {
+		err = c.doOne(ctx, method, endpoint+"/webhdfs/v1"+path+"?"+params.Encode(), out)
+		if err == nil {
+			return nil
+		}
+		if _, remote := err.(*RemoteException); remote && !isStandby(err) {
+			// the active namenode rejected the operation
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) doOne(ctx context.Context, method, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var remote struct {
+			RemoteException RemoteException `json:"RemoteException"`
+		}
+		if err := json.Unmarshal(body, &remote); err != nil || remote.RemoteException.Exception == "" {
+			return fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Path, resp.StatusCode)
+		}
+		remote.RemoteException.StatusCode = resp.StatusCode
+		return &remote.RemoteException
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// FileStatus is the status of a file or directory
+type FileStatus struct {
+	PathSuffix       string `json:"pathSuffix"`
+	Type             string `json:"type"`
+	Owner            string `json:"owner"`
+	Group            string `json:"group"`
+	Permission       string `json:"permission"`
+	ModificationTime int64  `json:"modificationTime"`
+	ECPolicy         string `json:"ecPolicy"`
+	SnapshotEnabled  bool   `json:"snapshotEnabled"`
+}
+
+// GetFileStatus returns the status of the path
+func (c *Client) GetFileStatus(ctx context.Context, path string) (FileStatus, error) {
+	var res struct {
+		FileStatus FileStatus `json:"FileStatus"`
+	}
+	err := c.do(ctx, http.MethodGet, path, "GETFILESTATUS", nil, &res)
+	return res.FileStatus, err
+}
+
+// ListStatus returns the status of the entries of the directory
+func (c *Client) ListStatus(ctx context.Context, path string) ([]FileStatus, error) {
+	var res struct {
+		FileStatuses struct {
+			FileStatus []FileStatus `json:"FileStatus"`
+		} `json:"FileStatuses"`
+	}
+	err := c.do(ctx, http.MethodGet, path, "LISTSTATUS", nil, &res)
+	return res.FileStatuses.FileStatus, err
+}
+
+// Mkdirs creates the directory and its missing parents
+func (c *Client) Mkdirs(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodPut, path, "MKDIRS", nil, nil)
+}
+
+// Delete removes the path, with its content if recursive
+func (c *Client) Delete(ctx context.Context, path string, recursive bool) error {
+	return c.do(ctx, http.MethodDelete, path, "DELETE",
+		url.Values{"recursive": {strconv.FormatBool(recursive)}}, nil)
+}
+
+// SetOwner changes the owner and the group of the path, empty values are left unchanged
+func (c *Client) SetOwner(ctx context.Context, path, owner, group string) error {
+	params := url.Values{}
+	if owner != "" {
+		params.Set("owner", owner)
+	}
+	if group != "" {
+		params.Set("group", group)
+	}
+	return c.do(ctx, http.MethodPut, path, "SETOWNER", params, nil)
+}
+
+// SetPermission changes the octal permission of the path
+func (c *Client) SetPermission(ctx context.Context, path, permission string) error {
+	return c.do(ctx, http.MethodPut, path, "SETPERMISSION", url.Values{"permission": {permission}}, nil)
+}
+
+// GetStoragePolicy returns the name of the storage policy in effect for the path
+func (c *Client) GetStoragePolicy(ctx context.Context, path string) (string, error) {
+	var res struct {
+		BlockStoragePolicy struct {
+			Name string `json:"name"`
+		} `json:"BlockStoragePolicy"`
+	}
+	err := c.do(ctx, http.MethodGet, path, "GETSTORAGEPOLICY", nil, &res)
+	return res.BlockStoragePolicy.Name, err
+}
+
+// SetStoragePolicy sets the storage policy of the path
+func (c *Client) SetStoragePolicy(ctx context.Context, path, policy string) error {
+	return c.do(ctx, http.MethodPut, path, "SETSTORAGEPOLICY", url.Values{"storagepolicy": {policy}}, nil)
+}
+
+// EnableECPolicy enables the erasure coding policy cluster wide
+func (c *Client) EnableECPolicy(ctx context.Context, policy string) error {
+	return c.do(ctx, http.MethodPut, "/", "ENABLEECPOLICY", url.Values{"ecpolicy": {policy}}, nil)
+}
+
+// SetECPolicy sets the erasure coding policy of the directory
+func (c *Client) SetECPolicy(ctx context.Context, path, policy string) error {
+	return c.do(ctx, http.MethodPut, path, "SETECPOLICY", url.Values{"ecpolicy": {policy}}, nil)
+}
+
+// ContentSummary is the usage and quotas of a directory
+type ContentSummary struct {
+	DirectoryCount int64 `json:"directoryCount"`
+	FileCount      int64 `json:"fileCount"`
+	Length         int64 `json:"length"`
+	Quota          int64 `json:"quota"`
+	SpaceConsumed  int64 `json:"spaceConsumed"`
+	SpaceQuota     int64 `json:"spaceQuota"`
+}
+
+// GetContentSummary returns the usage and quotas of the directory
+func (c *Client) GetContentSummary(ctx context.Context, path string) (ContentSummary, error) {
+	var res struct {
+		ContentSummary ContentSummary `json:"ContentSummary"`
+	}
+	err := c.do(ctx, http.MethodGet, path, "GETCONTENTSUMMARY", nil, &res)
+	return res.ContentSummary, err
+}
+
+const (
+	// QuotaDontSet leaves a quota unchanged
+	QuotaDontSet int64 = 1<<63 - 1
+	// QuotaReset removes a quota
+	QuotaReset int64 = -1
+)
+
+// SetQuota sets the name and space quotas of the directory
+func (c *Client) SetQuota(ctx context.Context, path string, nameQuota, spaceQuota int64) error {
+	return c.do(ctx, http.MethodPut, path, "SETQUOTA", url.Values{
+		"namespacequota":    {strconv.FormatInt(nameQuota, 10)},
+		"storagespacequota": {strconv.FormatInt(spaceQuota, 10)},
+	}, nil)
+}
+
+// GetACLEntries returns the ACL entries of the path, without the user, group and other base entries
+func (c *Client) GetACLEntries(ctx context.Context, path string) ([]string, error) {
+	var res struct {
+		AclStatus struct {
+			Entries []string `json:"entries"`
+		} `json:"AclStatus"`
+	}
+	err := c.do(ctx, http.MethodGet, path, "GETACLSTATUS", nil, &res)
+	return res.AclStatus.Entries, err
+}
+
+// ModifyACLEntries adds or updates the given ACL entries of the path
+func (c *Client) ModifyACLEntries(ctx context.Context, path string, entries []string) error {
+	return c.do(ctx, http.MethodPut, path, "MODIFYACLENTRIES", url.Values{"aclspec": {strings.Join(entries, ",")}}, nil)
+}
+
+// RemoveACL removes the ACL entries of the path other than the base ones
+func (c *Client) RemoveACL(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodPut, path, "REMOVEACL", nil, nil)
+}
+
+// AllowSnapshot makes the directory snapshottable
+func (c *Client) AllowSnapshot(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodPut, path, "ALLOWSNAPSHOT", nil, nil)
+}
+
+// CreateSnapshot creates a snapshot of the directory and returns its path
+func (c *Client) CreateSnapshot(ctx context.Context, path, name string) (string, error) {
+	var res struct {
+		Path string `json:"Path"`
+	}
+	err := c.do(ctx, http.MethodPut, path, "CREATESNAPSHOT", url.Values{"snapshotname": {name}}, &res)
+	return res.Path, err
+}
+
+// DeleteSnapshot deletes a snapshot of the directory
+func (c *Client) DeleteSnapshot(ctx context.Context, path, name string) error {
+	return c.do(ctx, http.MethodDelete, path, "DELETESNAPSHOT", url.Values{"snapshotname": {name}}, nil)
+}
+
+// NameNodeInfo is the identity of the namespace served by the namenodes, as read from their JMX metrics
+type NameNodeInfo struct {
+	ClusterId   string `json:"ClusterId"`
+	BlockPoolId string `json:"BlockPoolId"`
+	Safemode    string `json:"Safemode"`
+}
+
+// GetNameNodeInfo returns the NameNodeInfo of the first namenode answering, standby namenodes included
+func (c *Client) GetNameNodeInfo(ctx context.Context) (NameNodeInfo, error) {
+	err := fmt.Errorf("no namenode endpoint")
+	for _, endpoint := range c.Endpoints {
+		var res struct {
+			Beans []NameNodeInfo `json:"beans"`
+		}
+		err = c.doOne(ctx, http.MethodGet, endpoint+"/jmx?qry=Hadoop:service=NameNode,name=NameNodeInfo", &res)
+		if err == nil && len(res.Beans) > 0 && res.Beans[0].ClusterId != "" {
+			return res.Beans[0], nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s: NameNodeInfo not available", endpoint)
+		}
+	}
+	return NameNodeInfo{}, err
+}
+
+// GetHAStates returns the HA state of each namenode endpoint, e.g. active or standby, empty for
+// the namenodes not answering.
+func (c *Client) GetHAStates(ctx context.Context) []string {
+	states := make([]string, len(c.Endpoints))
+	for i, endpoint := range c.Endpoints {
+		var res struct {
+			Beans []struct {
+				State string `json:"State"`
+			} `json:"beans"`
+		}
+		if err := c.doOne(ctx, http.MethodGet, endpoint+"/jmx?qry=Hadoop:service=NameNode,name=NameNodeStatus", &res); err == nil && len(res.Beans) > 0 {
+			states[i] = res.Beans[0].State
+		}
+	}
+	return states
+}
+
+// Admin states of the datanodes, as reported by the namenodes
+const (
+	AdminStateInService       = "In Service"
+	AdminStateDecommissioning = "Decommission In Progress"
+	AdminStateDecommissioned  = "Decommissioned"
+)
+
+// datanodeInfo is an entry of the LiveNodes, DeadNodes and DecomNodes attributes of NameNodeInfo
+type datanodeInfo struct {
+	XferAddr       string `json:"xferaddr"`
+	AdminState     string `json:"adminState"`
+	Decommissioned bool   `json:"decommissioned"`
+}
+
+// GetDatanodeAdminStates returns the admin state of the datanodes known to the active namenode,
+// keyed by their ip, their host name and its first label. Dead datanodes are decommissioned if
+// they were when they died, in service otherwise.
+func (c *Client) GetDatanodeAdminStates(ctx context.Context) (map[string]string, error) {
+	endpoint := ""
+	for i, state := range c.GetHAStates(ctx) {
+		if state == "active" {
+			endpoint = c.Endpoints[i]
+		}
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("no active namenode")
+	}
+
+	var res struct {
+		Beans []struct {
+			LiveNodes  string `json:"LiveNodes"`
+			DeadNodes  string `json:"DeadNodes"`
+			DecomNodes string `json:"DecomNodes"`
+		} `json:"beans"`
+	}
+	if err := c.doOne(ctx, http.MethodGet, endpoint+"/jmx?qry=Hadoop:service=NameNode,name=NameNodeInfo", &res); err != nil {
+		return nil, err
+	}
+	if len(res.Beans) == 0 {
+		return nil, fmt.Errorf("%s: NameNodeInfo not available", endpoint)
+	}
+
+	bean := res.Beans[0]
+	states := map[string]string{}
+	// the attributes are json documents themselves, mapping host:port to the datanode. The
+	// decommissioning ones come last, as they have no admin state of their own.
+	for i, attr := range []string{bean.DeadNodes, bean.LiveNodes, bean.DecomNodes} {
+		if attr == "" {
+			continue
+		}
+		var nodes map[string]datanodeInfo
+		if err := json.Unmarshal([]byte(attr), &nodes); err != nil {
+			return nil, fmt.Errorf("%s: NameNodeInfo: %w", endpoint, err)
+		}
+		for name, node := range nodes {
+			state := node.AdminState
+			switch {
+			case i == 0 && node.Decommissioned:
+				state = AdminStateDecommissioned
+			case i == 0:
+				state = AdminStateInService
+			case i == 2:
+				state = AdminStateDecommissioning
+			}
+			host := hostOf(name)
+			for _, key := range []string{hostOf(node.XferAddr), host, strings.SplitN(host, ".", 2)[0]} {
+				if key != "" {
+					states[key] = state
+				}
+			}
+		}
+	}
+	return states, nil
+}
+
+// hostOf strips the port of a host:port address
+func hostOf(addr string) string {
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		return addr[:i]
+	}
+	return addr
+}
@@ -0,0 +1,59 @@
+package webhdfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jmxNamenode serves the NameNodeStatus and NameNodeInfo beans of a namenode in the given HA state
+func jmxNamenode(state string, info map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bean := map[string]string{"State": state}
+		if strings.Contains(r.URL.RawQuery, "NameNodeInfo") {
+			bean = info
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"beans": []map[string]string{bean}})
+	}
+}
+
+func TestGetDatanodeAdminStates(t *testing.T) {
+	standby := httptest.NewServer(jmxNamenode("standby", map[string]string{"LiveNodes": "{}"}))
+	active := httptest.NewServer(jmxNamenode("active", map[string]string{
+		"LiveNodes": `{"dn-0.dn.ns.svc.cluster.local:9866":{"xferaddr":"10.0.0.1:9866","adminState":"In Service"},` +
+			`"dn-1.dn.ns.svc.cluster.local:9866":{"xferaddr":"10.0.0.2:9866","adminState":"Decommission In Progress"},` +
+			`"dn-2.dn.ns.svc.cluster.local:9866":{"xferaddr":"10.0.0.3:9866","adminState":"Decommissioned"}}`,
+		"DeadNodes":  `{"dn-3.dn.ns.svc.cluster.local:9866":{"xferaddr":"10.0.0.4:9866","decommissioned":true}}`,
+		"DecomNodes": `{"dn-1.dn.ns.svc.cluster.local:9866":{"xferaddr":"10.0.0.2:9866","underReplicatedBlocks":3}}`,
+	}))
+	t.Cleanup(standby.Close)
+	t.Cleanup(active.Close)
+
+	states, err := NewClient([]string{standby.URL, active.URL}, DefaultUser).GetDatanodeAdminStates(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, expected := range map[string]string{
+		"10.0.0.1":                     AdminStateInService,
+		"dn-0.dn.ns.svc.cluster.local": AdminStateInService,
+		"dn-1":                         AdminStateDecommissioning,
+		"10.0.0.3":                     AdminStateDecommissioned,
+		"dn-3":                         AdminStateDecommissioned,
+	} {
+		if states[host] != expected {
+			t.Errorf("state of %s: expected %q, got %q", host, expected, states[host])
+		}
+	}
+}
+
+func TestGetDatanodeAdminStatesWithoutActiveNamenode(t *testing.T) {
+	standby := httptest.NewServer(jmxNamenode("standby", map[string]string{"LiveNodes": "{}"}))
+	t.Cleanup(standby.Close)
+
+	if _, err := NewClient([]string{standby.URL}, DefaultUser).GetDatanodeAdminStates(context.Background()); err == nil {
+		t.Error("expected an error without an active namenode")
+	}
+}
@@ -0,0 +1,95 @@
+package yarn
+
+import (
+	"fmt"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+)
+
+const (
+	defaultScaleUpPendingMB            = 1024
+	defaultScaleUpPendingVCores        = 1
+	defaultScaleDownUtilizationPercent = 50
+	defaultDecommissionTimeoutSeconds  = 600
+)
+
+// ScaleUpPendingMB returns the pending memory adding a NodeManager
+func ScaleUpPendingMB(scaling v1.NMAutoscaling) int64 {
+	if scaling.ScaleUpPendingMB > 0 {
+		return scaling.ScaleUpPendingMB
+	}
+	return defaultScaleUpPendingMB
+}
+
+// ScaleUpPendingVCores returns the pending vcores adding a NodeManager
+func ScaleUpPendingVCores(scaling v1.NMAutoscaling) int64 {
+	if scaling.ScaleUpPendingVCores > 0 {
+		return scaling.ScaleUpPendingVCores
+	}
+	return defaultScaleUpPendingVCores
+}
+
+// ScaleDownUtilizationPercent returns the share of the remaining NodeManagers the allocated
+// resources must stay under for a NodeManager to be removed
+func ScaleDownUtilizationPercent(scaling v1.NMAutoscaling) int64 {
+	if scaling.ScaleDownUtilizationPercent > 0 {
+		return int64(scaling.ScaleDownUtilizationPercent)
+	}
+	return defaultScaleDownUtilizationPercent
+}
+
+// DecommissionTimeoutSeconds returns how long a decommissioned NodeManager may keep running containers
+func DecommissionTimeoutSeconds(scaling v1.NMAutoscaling) int64 {
+	if scaling.DecommissionTimeoutSeconds > 0 {
+		return int64(scaling.DecommissionTimeoutSeconds)
+	}
+	return defaultDecommissionTimeoutSeconds
+}
+
+// InitialNMReplicas returns the NodeManagers the StatefulSet is created with, the autoscaler
+// owning the replicas of the StatefulSet afterwards
+func InitialNMReplicas(hdfs v1.HDFS) (int32, error) {
+	scaling := hdfs.Spec.Yarn.Autoscaling
+	if scaling == nil {
+		return hdfs.Spec.Yarn.NMReplicas, nil
+	}
+	if scaling.MaxReplicas < scaling.MinReplicas {
+		return 0, fmt.Errorf("yarn: autoscaling maxReplicas %d is below minReplicas %d", scaling.MaxReplicas, scaling.MinReplicas)
+	}
+	return boundNodeManagers(*scaling, hdfs.Spec.Yarn.NMReplicas), nil
+}
+
+func boundNodeManagers(scaling v1.NMAutoscaling, replicas int32) int32 {
+	if replicas < scaling.MinReplicas {
+		return scaling.MinReplicas
+	}
+	if replicas > scaling.MaxReplicas {
+		return scaling.MaxReplicas
+	}
+	return replicas
+}
+
+// DesiredNodeManagers returns the NodeManagers needed by the applications of the cluster, one
+// more while resources are pending, one less while the others could run the allocated ones.
+// Scaling one NodeManager at a time lets the metrics account for the last change.
+func DesiredNodeManagers(scaling v1.NMAutoscaling, current int32, metrics ClusterMetrics) int32 {
+	if bounded := boundNodeManagers(scaling, current); bounded != current {
+		return bounded
+	}
+	if metrics.PendingMB >= ScaleUpPendingMB(scaling) || metrics.PendingVirtualCores >= ScaleUpPendingVCores(scaling) {
+		if current < scaling.MaxReplicas {
+			return current + 1
+		}
+		return current
+	}
+	if current <= scaling.MinReplicas || metrics.PendingMB > 0 || metrics.PendingVirtualCores > 0 {
+		return current
+	}
+	percent := ScaleDownUtilizationPercent(scaling)
+	remainingMB := metrics.TotalMB * int64(current-1) / int64(current)
+	remainingVCores := metrics.TotalVirtualCores * int64(current-1) / int64(current)
+	if metrics.AllocatedMB*100 < remainingMB*percent && metrics.AllocatedVirtualCores*100 < remainingVCores*percent {
+		return current - 1
+	}
+	return current
+}
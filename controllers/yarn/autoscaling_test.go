@@ -0,0 +1,68 @@
+package yarn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+)
+
+func TestDesiredNodeManagers(t *testing.T) {
+	scaling := v1.NMAutoscaling{MinReplicas: 2, MaxReplicas: 5}
+	idle := ClusterMetrics{TotalMB: 4 * 8192, TotalVirtualCores: 4 * 4}
+	tests := []struct {
+		name    string
+		current int32
+		metrics ClusterMetrics
+		want    int32
+	}{
+		{"below min", 1, idle, 2},
+		{"above max", 7, idle, 5},
+		{"pending memory", 3, ClusterMetrics{PendingMB: 2048, TotalMB: 3 * 8192, TotalVirtualCores: 12}, 4},
+		{"pending vcores", 3, ClusterMetrics{PendingMB: 512, PendingVirtualCores: 1, TotalMB: 3 * 8192, TotalVirtualCores: 12}, 4},
+		{"pending at max", 5, ClusterMetrics{PendingMB: 2048, PendingVirtualCores: 2}, 5},
+		{"idle", 4, idle, 3},
+		{"idle at min", 2, ClusterMetrics{TotalMB: 2 * 8192, TotalVirtualCores: 8}, 2},
+		// 12288MB fits in half of the 3 remaining NodeManagers only
+		{"busy", 4, ClusterMetrics{AllocatedMB: 12288, AllocatedVirtualCores: 2, TotalMB: 4 * 8192, TotalVirtualCores: 16}, 4},
+		{"underused", 4, ClusterMetrics{AllocatedMB: 8192, AllocatedVirtualCores: 2, TotalMB: 4 * 8192, TotalVirtualCores: 16}, 3},
+		{"pending below thresholds", 4, ClusterMetrics{PendingMB: 512, TotalMB: 4 * 8192, TotalVirtualCores: 16}, 4},
+	}
+	for _, tt := range tests {
+		if got := DesiredNodeManagers(scaling, tt.current, tt.metrics); got != tt.want {
+			t.Errorf("%s: DesiredNodeManagers(%d) = %d, want %d", tt.name, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestGetClusterMetrics(t *testing.T) {
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://active"+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer standby.Close()
+	active := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws/v1/cluster/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"clusterMetrics":{"appsPending":1,"pendingMB":3072,"pendingVirtualCores":2,` +
+			`"allocatedMB":1024,"allocatedVirtualCores":1,"totalMB":8192,"totalVirtualCores":4,"activeNodes":1}}`))
+	}))
+	defer active.Close()
+
+	metrics, err := NewClient([]string{standby.URL, active.URL}).GetClusterMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterMetrics: %v", err)
+	}
+	want := ClusterMetrics{PendingMB: 3072, PendingVirtualCores: 2, AllocatedMB: 1024, AllocatedVirtualCores: 1,
+		TotalMB: 8192, TotalVirtualCores: 4, ActiveNodes: 1}
+	if metrics != want {
+		t.Errorf("GetClusterMetrics = %+v, want %+v", metrics, want)
+	}
+
+	if _, err := NewClient([]string{standby.URL}).GetClusterMetrics(context.Background()); err == nil {
+		t.Error("GetClusterMetrics succeeded without an active ResourceManager")
+	}
+}
@@ -0,0 +1,112 @@
+package yarn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Client calls the REST API of the ResourceManagers of a cluster
+type Client struct {
+	Endpoints  []string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the given ResourceManager web endpoints, e.g. http://rm-0:8088
+func NewClient(endpoints []string) *Client {
+	return &Client{
+		Endpoints: endpoints,
+		HTTPClient: &http.Client{
+			Timeout: defaultTimeout,
+			// a standby ResourceManager redirects most calls to the active one
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// RMEndpoints returns the web endpoints of the ResourceManagers of the cluster
+func RMEndpoints(hdfs v1.HDFS) []string {
+	name := RMStatefulSetName(hdfs)
+	var endpoints []string
+	for i := int32(0); i < hdfs.Spec.Yarn.RMReplicas; i++ {
+		endpoints = append(endpoints, fmt.Sprintf("http://%s-%d.%s.%s.svc.cluster.local:%d", name, i, name, hdfs.Namespace, RMWebPort))
+	}
+	return endpoints
+}
+
+// ForCluster returns a client for the ResourceManagers of the cluster
+func ForCluster(hdfs v1.HDFS) *Client {
+	return NewClient(RMEndpoints(hdfs))
+}
+
+func (c *Client) get(ctx context.Context, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %d", req.URL.Path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// GetHAStates returns the HA state of each ResourceManager endpoint in lower case, e.g. active or
+// standby, empty for the ResourceManagers not answering.
+func (c *Client) GetHAStates(ctx context.Context) []string {
+	states := make([]string, len(c.Endpoints))
+	for i, endpoint := range c.Endpoints {
+		var res struct {
+			ClusterInfo struct {
+				HAState string `json:"haState"`
+			} `json:"clusterInfo"`
+		}
+		if err := c.get(ctx, endpoint+"/ws/v1/cluster/info", &res); err == nil {
+			states[i] = strings.ToLower(res.ClusterInfo.HAState)
+		}
+	}
+	return states
+}
+
+// ClusterMetrics are the resources of the cluster, as reported by the active ResourceManager
+type ClusterMetrics struct {
+	PendingMB             int64 `json:"pendingMB"`
+	PendingVirtualCores   int64 `json:"pendingVirtualCores"`
+	AllocatedMB           int64 `json:"allocatedMB"`
+	AllocatedVirtualCores int64 `json:"allocatedVirtualCores"`
+	TotalMB               int64 `json:"totalMB"`
+	TotalVirtualCores     int64 `json:"totalVirtualCores"`
+	ActiveNodes           int32 `json:"activeNodes"`
+	DecommissioningNodes  int32 `json:"decommissioningNodes"`
+}
+
+// GetClusterMetrics returns the metrics of the first ResourceManager answering, standby ones
+// redirecting to the active one.
+func (c *Client) GetClusterMetrics(ctx context.Context) (ClusterMetrics, error) {
+	var lastErr error
+	for _, endpoint := range c.Endpoints {
+		var res struct {
+			ClusterMetrics ClusterMetrics `json:"clusterMetrics"`
+		}
+		if lastErr = c.get(ctx, endpoint+"/ws/v1/cluster/metrics", &res); lastErr == nil {
+			return res.ClusterMetrics, nil
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no ResourceManager endpoint")
+	}
+	return ClusterMetrics{}, lastErr
+}
@@ -1,86 +1,47 @@
 package yarn
 
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+)
+
 const (
-	YarnConfigName       = "yarn-config"
-	//MapredSiteFileName   = "mapred-site.xml"
-	//YarnSiteFileName     = "yarn-site.xml"
+	YarnConfigName = "yarn-config"
 )
 
-//func BuildConfigMap(hdfs v1.HDFS) (corev1.ConfigMap, error) {
-//
-//	mapredSiteData, err := RenderMapredSiteCfg(hdfs.Spec.Yarn.MapredSite)
-//	if err != nil {
-//		return corev1.ConfigMap{}, err
-//	}
-//	yarnSiteData, err := RenderYarnSiteCfg(hdfs)
-//	if err != nil {
-//		return corev1.ConfigMap{}, err
-//	}
-//	return corev1.ConfigMap{
-//		TypeMeta: metav1.TypeMeta{
-//			Kind:       "ConfigMap",
-//			APIVersion: "v1",
-//		},
-//		ObjectMeta: metav1.ObjectMeta{
-//			Namespace: hdfs.Namespace,
-//			Name:      com.GetName(hdfs.Name, YarnConfigName),
-//			OwnerReferences: com.GetOwnerReference(hdfs),
-//		},
-//		Data: map[string]string{
-//			MapredSiteFileName: string(mapredSiteData),
-//			YarnSiteFileName: string(yarnSiteData),
-//		},
-//	}, nil
-//
-//}
+// ConfigMapName returns the name of the ConfigMap of the YARN cluster
+func ConfigMapName(hdfs v1.HDFS) string {
+	return com.GetName(com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name), "config")
+}
+
+// SecretName returns the name of the Secret holding the secret configuration of the YARN cluster
+func SecretName(hdfs v1.HDFS) string {
+	return com.GetName(com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name), com.SecretConfigName)
+}
+
+// BuildConfigMap renders the configuration of the YARN daemons: yarn-site.xml and mapred-site.xml
+// along with the core-site.xml and hdfs-site.xml of the filesystem, the HDFS config map holding
+// the latter only.
+func BuildConfigMap(hdfs v1.HDFS, ext com.ExternalConfig) (corev1.ConfigMap, error) {
+	config, err := com.BuildHdfsConfig(hdfs, ConfigMapName(hdfs), ext)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	yarnSiteData, err := com.RenderYarnSiteCfg(hdfs, ext)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	mapredSiteData, err := com.RenderMapredSiteCfg(hdfs, ext)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+	config.Data[com.YarnSiteFileName] = string(yarnSiteData)
+	config.Data[com.MapredSiteFileName] = string(mapredSiteData)
+	return config, nil
+}
 
-//func RenderMapredSiteCfg(cfgs []v1.ClusterConfig) ([]byte, error) {
-//
-//	var c = com.Configuration{}
-//
-//	c.Configuration = append(c.Configuration, com.Property{
-//		Name:  "mapreduce.framework.name",
-//		Value: "yarn",
-//	},
-//	)
-//	for _, cfg := range cfgs {
-//		c.Configuration = append(c.Configuration, com.Property{
-//			Name:  cfg.Property,
-//			Value: cfg.Value,
-//		})
-//	}
-//	return xml.MarshalIndent(c, " ", " ")
-//}
-//
-//func RenderYarnSiteCfg(hdfs v1.HDFS) ([]byte, error) {
-//
-//	var c = com.Configuration{}
-//
-//	rmPrefix := com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-rm"
-//	rmService := rmPrefix+"."+hdfs.Namespace+".svc.cluster.local"
-//
-//	c.Configuration = append(c.Configuration, com.Property{
-//		Name:  "yarn.resourcemanager.hostname",
-//		Value: rmPrefix+"-0." + rmService,
-//	}, com.Property{
-//		Name:  "yarn.nodemanager.vmem-check-enabled",
-//		Value: "false",
-//	}, com.Property{
-//		Name:  "yarn.nodemanager.aux-services",
-//		Value: "mapreduce_shuffle",
-//	}, com.Property{
-//		Name:  "yarn.nodemanager.aux-services.mapreduce_shuffle.class",
-//		Value: "org.apache.hadoop.mapred.ShuffleHandler",
-//	},com.Property{
-//		Name:  "yarn.nodemanager.remote-app-log-dir",
-//		Value: "/var/log/hadoop-yarn/apps",
-//	},
-//	)
-//	for _, cfg := range hdfs.Spec.Yarn.YarnSite {
-//		c.Configuration = append(c.Configuration, com.Property{
-//			Name:  cfg.Property,
-//			Value: cfg.Value,
-//		})
-//	}
-//	return xml.MarshalIndent(c, " ", " ")
-//}
\ No newline at end of file
+// BuildSecret renders the secret configuration of the YARN daemons
+func BuildSecret(hdfs v1.HDFS, ext com.ExternalConfig) (corev1.Secret, error) {
+	return com.BuildSecretConfig(hdfs, SecretName(hdfs), ext)
+}
@@ -0,0 +1,162 @@
+package yarn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	ExcludeConfigName   = "nm-exclude"
+	ExcludeVolumeName   = "nm-exclude"
+	RefreshNodesJobName = "refresh-nodes"
+
+	// NMReplicasAnnotation is set on the exclude config map with the NodeManagers the autoscaler
+	// scales to, the ones above being decommissioned
+	NMReplicasAnnotation = "dataomnis.io/nm-replicas"
+
+	// configPropagationSeconds leaves the kubelets time to update the exclude file mounted by the ResourceManagers
+	configPropagationSeconds = 90
+)
+
+var refreshNodesBackoffLimit int32 = 3
+
+// ExcludeConfigMapName returns the name of the config map holding the exclude file of the ResourceManagers
+func ExcludeConfigMapName(hdfs v1.HDFS) string {
+	return com.GetName(com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name), ExcludeConfigName)
+}
+
+// Hosts returns the addresses the ResourceManagers may know the given NodeManager pods by
+func Hosts(hdfs v1.HDFS, pods []corev1.Pod) []string {
+	var hosts []string
+	for _, pod := range pods {
+		hosts = append(hosts, fmt.Sprintf("%s.%s.%s.svc.cluster.local", pod.Name, NMStatefulSetName(hdfs), pod.Namespace))
+		if pod.Status.PodIP != "" {
+			hosts = append(hosts, pod.Status.PodIP)
+		}
+	}
+	// sort for a stable config map content
+	sort.Strings(hosts)
+	return hosts
+}
+
+// BuildExclude renders the exclude file listing the NodeManagers to decommission
+func BuildExclude(hdfs v1.HDFS, pods []corev1.Pod) string {
+	hosts := Hosts(hdfs, pods)
+	if len(hosts) == 0 {
+		return ""
+	}
+	return strings.Join(hosts, "\n") + "\n"
+}
+
+// BuildExcludeConfigMap builds the config map holding the exclude file, annotated with the
+// NodeManagers to keep
+func BuildExcludeConfigMap(hdfs v1.HDFS, exclude string, replicas int32) corev1.ConfigMap {
+	configmap := types.NamespacedName{Namespace: hdfs.Namespace, Name: ExcludeConfigMapName(hdfs)}
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            configmap.Name,
+			Namespace:       configmap.Namespace,
+			Labels:          com.NewLabels(configmap),
+			Annotations:     map[string]string{NMReplicasAnnotation: strconv.Itoa(int(replicas))},
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Data: map[string]string{
+			com.NMExcludeFileName: exclude,
+		},
+	}
+}
+
+// buildExcludeVolume returns the volume exposing the exclude config map to the ResourceManagers
+func buildExcludeVolume(hdfs v1.HDFS) com.ConfigMapVolume {
+	return com.NewConfigMapVolumeWithMode(ExcludeConfigMapName(hdfs),
+		ExcludeVolumeName,
+		com.NMExcludeConfigMountPath,
+		0444)
+}
+
+// JobName returns the name of the job applying the given exclude file. The job is deleted once
+// applied, for the same content to be applied again later on.
+func JobName(hdfs v1.HDFS, exclude string) string {
+	sum := sha256.Sum256([]byte(exclude))
+	return com.GetName(com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name), RefreshNodesJobName) + "-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// BuildRefreshNodesJob builds the job making the ResourceManagers read the exclude file again.
+// It waits for the containers of the listed NodeManagers to complete, up to the decommission
+// timeout after which they are killed.
+func BuildRefreshNodesJob(hdfs v1.HDFS, exclude string) batchv1.Job {
+	job := types.NamespacedName{Namespace: hdfs.Namespace, Name: JobName(hdfs, exclude)}
+
+	var timeout int64 = defaultDecommissionTimeoutSeconds
+	if hdfs.Spec.Yarn.Autoscaling != nil {
+		timeout = DecommissionTimeoutSeconds(*hdfs.Spec.Yarn.Autoscaling)
+	}
+	// leave the ResourceManagers some time to kill the containers after the timeout
+	deadline := configPropagationSeconds + 2*timeout
+	volumes, volumeMounts := buildVolumes(hdfs)
+	yarnCmd := "/opt/hadoop-" + hdfs.Spec.Version + "/bin/yarn --config /etc/hadoop"
+
+	refresh := batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            job.Name,
+			Namespace:       job.Namespace,
+			Labels:          com.NewLabels(job),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &refreshNodesBackoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: com.NewJobPodLabels(com.ExtractNamespacedName(&hdfs), job),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:    corev1.RestartPolicyOnFailure,
+					ImagePullSecrets: imagePullSecrets(hdfs.Spec.ImagePullSecrets),
+					Volumes:          volumes,
+					Containers: []corev1.Container{
+						{
+							Name:            RefreshNodesJobName,
+							Image:           hdfs.Spec.Image,
+							ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
+							Env:             envVars(),
+							Command:         []string{"/entrypoint.sh"},
+							Args: []string{"/bin/sh", "-c", fmt.Sprintf("sleep %d && %s rmadmin -refreshNodes -g %d -client",
+								configPropagationSeconds, yarnCmd, timeout)},
+							VolumeMounts: volumeMounts,
+						},
+					},
+				},
+			},
+		},
+	}
+	com.RestrictPodSpec(hdfs, &refresh.Spec.Template.Spec)
+	return refresh
+}
+
+func imagePullSecrets(names []string) []corev1.LocalObjectReference {
+	var secrets []corev1.LocalObjectReference
+	for _, name := range names {
+		secrets = append(secrets, corev1.LocalObjectReference{Name: name})
+	}
+	return secrets
+}
@@ -8,9 +8,19 @@ import (
 
 var defaultOptional = true
 
+const (
+	RMWebPort = 8088
+	nmWebPort = 8042
+)
+
 // BuildRMPodTemplate builds a new PodTemplateSpec for NameNode.
 func BuildRMPodTemplate(hdfs v1.HDFS, labels map[string]string) (corev1.PodTemplateSpec, error) {
-	volumes, volumeMounts := buildVolumes(hdfs.Name)
+	volumes, volumeMounts := buildVolumes(hdfs)
+	if hdfs.Spec.Yarn.Autoscaling != nil {
+		excludeVolume := buildExcludeVolume(hdfs)
+		volumes = append(volumes, excludeVolume.Volume())
+		volumeMounts = append(volumeMounts, excludeVolume.VolumeMount())
+	}
 
 	container := buildRMContainer(com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name), volumeMounts,hdfs)
 
@@ -21,6 +31,9 @@ func BuildRMPodTemplate(hdfs v1.HDFS, labels map[string]string) (corev1.PodTempl
 		WithRestartPolicy(corev1.RestartPolicyAlways).
 		//WithHostNetwork(defaultOptional).
 		//WithDNSPolicy(corev1.DNSClusterFirstWithHostNet).
+		WithPodSecurity(hdfs).
+		WithDefaultAntiAffinity(labels).
+		WithDefaultTopologySpread(labels).
 		WithTemplateMetadata(labels)
 
 	return builder.PodTemplate, nil
@@ -28,7 +41,10 @@ func BuildRMPodTemplate(hdfs v1.HDFS, labels map[string]string) (corev1.PodTempl
 
 // BuildNMPodTemplate builds a new PodTemplateSpec for NameNode.
 func BuildNMPodTemplate(hdfs v1.HDFS, labels map[string]string) (corev1.PodTemplateSpec, error) {
-	volumes, volumeMounts := buildVolumes(hdfs.Name)
+	volumes, volumeMounts := buildVolumes(hdfs)
+	nmVolumes, nmVolumeMounts := buildNMVolumes(hdfs)
+	volumes = append(volumes, nmVolumes...)
+	volumeMounts = append(volumeMounts, nmVolumeMounts...)
 
 	container := buildNMContainer(com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name), volumeMounts,hdfs)
 
@@ -39,14 +55,17 @@ func BuildNMPodTemplate(hdfs v1.HDFS, labels map[string]string) (corev1.PodTempl
 		WithRestartPolicy(corev1.RestartPolicyAlways).
 		//WithHostNetwork(defaultOptional).
 		//WithDNSPolicy(corev1.DNSClusterFirstWithHostNet).
+		WithPodSecurity(hdfs).
+		WithDefaultAntiAffinity(labels).
+		WithDefaultTopologySpread(labels).
 		WithTemplateMetadata(labels)
 
 	return builder.PodTemplate, nil
 }
 
-func buildVolumes(name string) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
+func buildVolumes(hdfs v1.HDFS) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
 
-	configVolume := com.NewConfigMapVolume(com.GetName(name, com.CommonConfigName),
+	configVolume := com.NewProjectedConfigVolume(ConfigMapName(hdfs), SecretName(hdfs),
 		YarnConfigName,
 		com.HdfsConfigMountPath)
 
@@ -56,37 +75,75 @@ func buildVolumes(name string) (volumes []corev1.Volume, volumeMounts []corev1.V
 	return volumes, volumeMounts
 }
 
+// buildNMVolumes mounts the local and log directories of the NodeManager, emptyDir volumes
+// unless claimed by the StatefulSet.
+func buildNMVolumes(hdfs v1.HDFS) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
+	for _, dir := range []struct{ name, path string }{
+		{NMLocalVolumeName, com.NMLocalDirsPath},
+		{NMLogVolumeName, com.NMLogDirsPath},
+	} {
+		if hdfs.Spec.Yarn.NMStorage == nil {
+			volumes = append(volumes, corev1.Volume{
+				Name:         dir.name,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: dir.name, MountPath: dir.path})
+	}
+	return volumes, volumeMounts
+}
+
 func buildRMContainer(name string, volumeMounts []corev1.VolumeMount,  hdfs v1.HDFS) corev1.Container {
 	defaultContainerPorts := getRMContainerPorts()
-	return corev1.Container{
+	return rmProbes().WithProbes(corev1.Container{
 		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
 		Image:           hdfs.Spec.Image,
 		Name:            name,
-		Env:             envVars(),
+		Env:             append(envVars(), com.HeapEnvVars(hdfs.Spec.Version, com.ResourcemanagerRole, hdfs.Spec.Yarn.RMResources, hdfs.Spec.Yarn.RMHeapPercent)...),
 		Command:         []string{"/entrypoint.sh"},
 		Args:            []string{"/opt/hadoop-"+hdfs.Spec.Version+"/bin/yarn", "--config", "/etc/hadoop", "resourcemanager"},
 		Ports:           defaultContainerPorts,
 		VolumeMounts:    volumeMounts,
-	}
+		Resources:       hdfs.Spec.Yarn.RMResources,
+	}, hdfs.Spec.Yarn.RMProbes)
 }
 
 func buildNMContainer(name string, volumeMounts []corev1.VolumeMount,hdfs v1.HDFS) corev1.Container {
 	defaultContainerPorts := getNMContainerPorts()
-	return corev1.Container{
+	return nmProbes().WithProbes(corev1.Container{
 		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
 		Image:           hdfs.Spec.Image,
 		Name:            name,
-		Env:             envVars(),
+		Env:             append(envVars(), com.HeapEnvVars(hdfs.Spec.Version, com.NodemanagerRole, hdfs.Spec.Yarn.NMResources, com.NMHeapPercent(hdfs.Spec.Yarn.NMHeapPercent))...),
 		Command:         []string{"/entrypoint.sh"},
 		Args:            []string{"/opt/hadoop-"+hdfs.Spec.Version+"/bin/yarn", "--config", "/etc/hadoop", "nodemanager"},
 		Ports:           defaultContainerPorts,
 		VolumeMounts:    volumeMounts,
+		Resources:       hdfs.Spec.Yarn.NMResources,
+	}, hdfs.Spec.Yarn.NMProbes)
+}
+
+// rmProbes check the web port only, a standby ResourceManager does not listen on its RPC ports
+func rmProbes() com.RoleProbes {
+	return com.RoleProbes{
+		Startup:   com.TCPProbe(RMWebPort, 10, 30),
+		Readiness: com.HTTPCheckProbe(RMWebPort, "/ws/v1/cluster/info", 10, 3, `"state" *: *"STARTED"`),
+		Liveness:  com.TCPProbe(RMWebPort, 10, 3),
+	}
+}
+
+// nmProbes report the NodeManager ready while its health checker reports it healthy
+func nmProbes() com.RoleProbes {
+	return com.RoleProbes{
+		Startup:   com.TCPProbe(nmWebPort, 10, 30),
+		Readiness: com.HTTPCheckProbe(nmWebPort, "/ws/v1/node/info", 10, 3, `"nodeHealthy" *: *true`),
+		Liveness:  com.TCPProbe(nmWebPort, 10, 3),
 	}
 }
 
 func GetRMServicePorts() []corev1.ServicePort {
 	return []corev1.ServicePort{
-		{Name: "web", Port: int32(8088)},
+		{Name: "web", Port: int32(RMWebPort)},
 		{Name: "scheduler", Port: int32(8030)},
 		{Name: "resource", Port: int32(8031)},
 		{Name: "address", Port: int32(8032)},
@@ -96,7 +153,7 @@ func GetRMServicePorts() []corev1.ServicePort {
 
 func getRMContainerPorts() []corev1.ContainerPort {
 	return []corev1.ContainerPort{
-		{Name: "web", ContainerPort: int32(8088)},
+		{Name: "web", ContainerPort: int32(RMWebPort)},
 		{Name: "scheduler", ContainerPort: int32(8030)},
 		{Name: "resource", ContainerPort: int32(8031)},
 		{Name: "address", ContainerPort: int32(8032)},
@@ -107,14 +164,14 @@ func getRMContainerPorts() []corev1.ContainerPort {
 func GetNMServicePorts() []corev1.ServicePort {
 	return []corev1.ServicePort{
 		{Name: "api", Port: int32(8040)},
-		{Name: "web", Port: int32(8042)},
+		{Name: "web", Port: int32(nmWebPort)},
 	}
 }
 
 func getNMContainerPorts() []corev1.ContainerPort {
 	return []corev1.ContainerPort{
 		{Name: "api", ContainerPort: int32(8040)},
-		{Name: "web", ContainerPort: int32(8042)},
+		{Name: "web", ContainerPort: int32(nmWebPort)},
 	}
 }
 
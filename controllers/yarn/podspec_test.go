@@ -0,0 +1,161 @@
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testHDFS() v1.HDFS {
+	return v1.HDFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "hdfs", Namespace: "default"},
+		Spec: v1.HDFSSpec{
+			Version: "3.2.2",
+			Yarn: v1.Yarn{
+				Name:       "yarn",
+				RMReplicas: 1,
+				NMReplicas: 2,
+			},
+		},
+	}
+}
+
+func mountPaths(container corev1.Container) map[string]string {
+	paths := make(map[string]string, len(container.VolumeMounts))
+	for _, m := range container.VolumeMounts {
+		paths[m.MountPath] = m.Name
+	}
+	return paths
+}
+
+// projectedSources returns the config maps and secrets projected by the volume named name
+func projectedSources(t *testing.T, pod corev1.PodTemplateSpec, name string) (configMaps, secrets []string) {
+	for _, v := range pod.Spec.Volumes {
+		if v.Name != name {
+			continue
+		}
+		if v.Projected == nil {
+			t.Fatalf("volume %s is not projected", name)
+		}
+		for _, s := range v.Projected.Sources {
+			if s.ConfigMap != nil {
+				configMaps = append(configMaps, s.ConfigMap.Name)
+			}
+			if s.Secret != nil {
+				secrets = append(secrets, s.Secret.Name)
+			}
+		}
+		return configMaps, secrets
+	}
+	t.Fatalf("volume %s not found", name)
+	return nil, nil
+}
+
+func TestPodTemplatesMountConfig(t *testing.T) {
+	hdfs := testHDFS()
+	rm, err := BuildRMPodTemplate(hdfs, map[string]string{})
+	if err != nil {
+		t.Fatalf("BuildRMPodTemplate: %v", err)
+	}
+	nm, err := BuildNMPodTemplate(hdfs, map[string]string{})
+	if err != nil {
+		t.Fatalf("BuildNMPodTemplate: %v", err)
+	}
+	for role, pod := range map[string]corev1.PodTemplateSpec{"resourcemanager": rm, "nodemanager": nm} {
+		volume, ok := mountPaths(pod.Spec.Containers[0])[com.HdfsConfigMountPath]
+		if !ok {
+			t.Fatalf("%s: nothing mounted at %s", role, com.HdfsConfigMountPath)
+		}
+		configMaps, secrets := projectedSources(t, pod, volume)
+		if len(configMaps) != 1 || configMaps[0] != ConfigMapName(hdfs) {
+			t.Errorf("%s: projected config maps = %v, want [%s]", role, configMaps, ConfigMapName(hdfs))
+		}
+		if len(secrets) != 1 || secrets[0] != SecretName(hdfs) {
+			t.Errorf("%s: projected secrets = %v, want [%s]", role, secrets, SecretName(hdfs))
+		}
+	}
+}
+
+func TestNodeManagerDirs(t *testing.T) {
+	hdfs := testHDFS()
+	rm, err := BuildRMPodTemplate(hdfs, map[string]string{})
+	if err != nil {
+		t.Fatalf("BuildRMPodTemplate: %v", err)
+	}
+	nm, err := BuildNMPodTemplate(hdfs, map[string]string{})
+	if err != nil {
+		t.Fatalf("BuildNMPodTemplate: %v", err)
+	}
+	rmPaths, nmPaths := mountPaths(rm.Spec.Containers[0]), mountPaths(nm.Spec.Containers[0])
+	for path, volume := range map[string]string{com.NMLocalDirsPath: NMLocalVolumeName, com.NMLogDirsPath: NMLogVolumeName} {
+		if got := nmPaths[path]; got != volume {
+			t.Errorf("nodemanager mounts %q at %s, want %q", got, path, volume)
+		}
+		if got, ok := rmPaths[path]; ok {
+			t.Errorf("resourcemanager mounts %q at %s", got, path)
+		}
+	}
+
+	// emptyDir volumes back the directories unless claimed by the StatefulSet
+	var emptyDirs int
+	for _, v := range nm.Spec.Volumes {
+		if v.EmptyDir != nil {
+			emptyDirs++
+		}
+	}
+	if emptyDirs != 2 {
+		t.Errorf("nodemanager has %d emptyDir volumes, want 2", emptyDirs)
+	}
+
+	hdfs.Spec.Yarn.NMStorage = &v1.NMStorage{LocalCapacity: "50Gi", LogCapacity: "5Gi"}
+	sset, err := BuildNMStatefulSet(hdfs)
+	if err != nil {
+		t.Fatalf("BuildNMStatefulSet: %v", err)
+	}
+	for _, v := range sset.Spec.Template.Spec.Volumes {
+		if v.Name == NMLocalVolumeName || v.Name == NMLogVolumeName {
+			t.Errorf("nodemanager declares volume %s claimed by the StatefulSet", v.Name)
+		}
+	}
+	claims := make(map[string]bool)
+	for _, pvc := range sset.Spec.VolumeClaimTemplates {
+		claims[pvc.Name] = true
+	}
+	if !claims[NMLocalVolumeName] || !claims[NMLogVolumeName] {
+		t.Errorf("volume claim templates = %v, want %s and %s", claims, NMLocalVolumeName, NMLogVolumeName)
+	}
+
+	hdfs.Spec.Yarn.NMStorage.LogCapacity = "lots"
+	if _, err := BuildNMStatefulSet(hdfs); err == nil {
+		t.Error("BuildNMStatefulSet accepted an invalid capacity")
+	}
+}
+
+func TestBuildConfigMap(t *testing.T) {
+	hdfs := testHDFS()
+	config, err := BuildConfigMap(hdfs, com.NewExternalConfig())
+	if err != nil {
+		t.Fatalf("BuildConfigMap: %v", err)
+	}
+	if config.Name != ConfigMapName(hdfs) {
+		t.Errorf("name = %s, want %s", config.Name, ConfigMapName(hdfs))
+	}
+	for _, file := range []string{com.CoreSiteFileName, com.HdfsSiteFileName, com.YarnSiteFileName, com.MapredSiteFileName} {
+		if _, ok := config.Data[file]; !ok {
+			t.Errorf("%s missing", file)
+		}
+	}
+	yarnSite := config.Data[com.YarnSiteFileName]
+	for _, want := range []string{"yarn.nodemanager.local-dirs", com.NMLocalDirsPath, "yarn.nodemanager.log-dirs", com.NMLogDirsPath} {
+		if !strings.Contains(yarnSite, want) {
+			t.Errorf("yarn-site.xml does not contain %s", want)
+		}
+	}
+	if !strings.Contains(config.Data[com.MapredSiteFileName], "mapreduce.framework.name") {
+		t.Error("mapred-site.xml does not set mapreduce.framework.name")
+	}
+}
@@ -0,0 +1,140 @@
+package yarn
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+const (
+	StatePvcName = "yarn-state"
+
+	defaultServerCapacity = "1Gi"
+)
+
+// BuildServerStatefulSets builds the StatefulSets of the JobHistory and timeline servers run
+func BuildServerStatefulSets(hdfs v1.HDFS) ([]appsv1.StatefulSet, error) {
+	var ssets []appsv1.StatefulSet
+	if server := hdfs.Spec.Yarn.HistoryServer; server != nil {
+		container := buildServerContainer(hdfs, *server, com.HistoryserverRole,
+			[]string{"/opt/hadoop-" + hdfs.Spec.Version + "/bin/mapred", "--config", "/etc/hadoop", "historyserver"},
+			GetJobHistoryServicePorts(), com.JobHistoryWebPort)
+		sset, err := buildServerStatefulSet(hdfs, *server, com.JobHistorySuffix, container)
+		if err != nil {
+			return nil, err
+		}
+		ssets = append(ssets, sset)
+	}
+	if server := hdfs.Spec.Yarn.TimelineServer; server != nil {
+		container := buildServerContainer(hdfs, *server, com.TimelineserverRole,
+			[]string{"/opt/hadoop-" + hdfs.Spec.Version + "/bin/yarn", "--config", "/etc/hadoop", "timelineserver"},
+			GetTimelineServicePorts(), com.TimelineWebPort)
+		sset, err := buildServerStatefulSet(hdfs, *server, com.TimelineSuffix, container)
+		if err != nil {
+			return nil, err
+		}
+		ssets = append(ssets, sset)
+	}
+	return ssets, nil
+}
+
+// BuildServerServices builds the headless Services of the JobHistory and timeline servers run
+func BuildServerServices(hdfs v1.HDFS) []corev1.Service {
+	var svc []corev1.Service
+	if hdfs.Spec.Yarn.HistoryServer != nil {
+		svc = append(svc, com.HeadlessService(hdfs, com.YarnServerName(hdfs, com.JobHistorySuffix), GetJobHistoryServicePorts()))
+	}
+	if hdfs.Spec.Yarn.TimelineServer != nil {
+		svc = append(svc, com.HeadlessService(hdfs, com.YarnServerName(hdfs, com.TimelineSuffix), GetTimelineServicePorts()))
+	}
+	return svc
+}
+
+func GetJobHistoryServicePorts() []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{Name: "rpc", Port: com.JobHistoryPort},
+		{Name: "web", Port: com.JobHistoryWebPort},
+		{Name: "admin", Port: 10033},
+	}
+}
+
+func GetTimelineServicePorts() []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{Name: "rpc", Port: com.TimelinePort},
+		{Name: "web", Port: com.TimelineWebPort},
+	}
+}
+
+func buildServerStatefulSet(hdfs v1.HDFS, server v1.YarnServer, suffix string, container corev1.Container) (appsv1.StatefulSet, error) {
+	statefulSetName := com.YarnServerName(hdfs, suffix)
+	// ssetSelector is used to match the StatefulSet pods
+	ssetSelector := com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), statefulSetName)
+
+	capacity := server.Capacity
+	if capacity == "" {
+		capacity = defaultServerCapacity
+	}
+	if _, err := resource.ParseQuantity(capacity); err != nil {
+		return appsv1.StatefulSet{}, fmt.Errorf("yarn server %s: capacity: %w", statefulSetName, err)
+	}
+
+	volumes, volumeMounts := buildVolumes(hdfs)
+	container.VolumeMounts = append(volumeMounts, corev1.VolumeMount{Name: StatePvcName, MountPath: com.YarnServerStatePath})
+
+	builder := &com.PodTemplateBuilder{}
+	builder.WithContainers(container).
+		WithSpecVolumes(volumes...).
+		WithImagePullSecrets(hdfs.Spec.ImagePullSecrets...).
+		WithRestartPolicy(corev1.RestartPolicyAlways).
+		WithPodSecurity(hdfs).
+		WithTemplateMetadata(ssetSelector)
+
+	replicas := int32(1)
+	return appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            statefulSetName,
+			Labels:          ssetSelector,
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: statefulSetName,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ssetSelector,
+			},
+			Replicas:             &replicas,
+			VolumeClaimTemplates: com.AppendPVCs(StatePvcName, server.StorageClass, capacity),
+			Template:             builder.PodTemplate,
+		},
+	}, nil
+}
+
+func buildServerContainer(hdfs v1.HDFS, server v1.YarnServer, role string, args []string, ports []corev1.ServicePort, webPort int) corev1.Container {
+	var containerPorts []corev1.ContainerPort
+	for _, port := range ports {
+		containerPorts = append(containerPorts, corev1.ContainerPort{Name: port.Name, ContainerPort: port.Port})
+	}
+	return com.RoleProbes{
+		Startup:   com.TCPProbe(webPort, 10, 30),
+		Readiness: com.TCPProbe(webPort, 10, 3),
+		Liveness:  com.TCPProbe(webPort, 10, 3),
+	}.WithProbes(corev1.Container{
+		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
+		Image:           hdfs.Spec.Image,
+		Name:            strings.ToLower(role),
+		Env:             append(envVars(), com.HeapEnvVars(hdfs.Spec.Version, role, server.Resources, 0)...),
+		Command:         []string{"/entrypoint.sh"},
+		Args:            args,
+		Ports:           containerPorts,
+		Resources:       server.Resources,
+	}, nil)
+}
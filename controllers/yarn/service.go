@@ -0,0 +1,54 @@
+package yarn
+
+import (
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// RMStatefulSetName returns the name of the ResourceManager StatefulSet and headless Service
+func RMStatefulSetName(hdfs v1.HDFS) string {
+	return com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name) + "-rm"
+}
+
+// NMStatefulSetName returns the name of the NodeManager StatefulSet and headless Service
+func NMStatefulSetName(hdfs v1.HDFS) string {
+	return com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name) + "-nm"
+}
+
+// ActiveRMServiceName returns the name of the Service of the active ResourceManager
+func ActiveRMServiceName(hdfs v1.HDFS) string {
+	return RMStatefulSetName(hdfs) + "-active"
+}
+
+// BuildActiveRMService builds the Service selecting the ResourceManager pod labelled active, the
+// standby ones not serving the RPC ports.
+func BuildActiveRMService(hdfs v1.HDFS) corev1.Service {
+	nsn := com.ExtractNamespacedName(&hdfs)
+	selector := com.NewStatefulSetLabels(nsn, RMStatefulSetName(hdfs))
+	selector[com.HAStateLabelName] = com.HAStateActive
+	var ports []corev1.ServicePort
+	for _, port := range GetRMServicePorts() {
+		port.TargetPort = intstr.FromInt(int(port.Port))
+		ports = append(ports, port)
+	}
+	return corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            ActiveRMServiceName(hdfs),
+			Labels:          com.NewLabels(nsn),
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: selector,
+			Ports:    ports,
+		},
+	}
+}
@@ -1,14 +1,22 @@
 package yarn
 
 import (
+	"fmt"
 	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
 	com "github.com/dataworkbench/hdfs-operator/common"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	NMLocalVolumeName = "nm-local"
+	NMLogVolumeName   = "nm-logs"
+)
+
 func BuildRMStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
-	statefulSetName := com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-rm"
+	statefulSetName := RMStatefulSetName(hdfs)
 	// ssetSelector is used to match the StatefulSet pods
 	ssetSelector := com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), statefulSetName)
 
@@ -41,10 +49,26 @@ func BuildRMStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
 }
 
 func BuildNMStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
-	statefulSetName := com.GetName(hdfs.Name, hdfs.Spec.Yarn.Name)+"-nm"
+	statefulSetName := NMStatefulSetName(hdfs)
 	// ssetSelector is used to match the StatefulSet pods
 	ssetSelector := com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), statefulSetName)
 
+	replicas, err := InitialNMReplicas(hdfs)
+	if err != nil {
+		return appsv1.StatefulSet{}, err
+	}
+
+	var volumeClaimTemplates []corev1.PersistentVolumeClaim
+	if storage := hdfs.Spec.Yarn.NMStorage; storage != nil {
+		for _, capacity := range []string{storage.LocalCapacity, storage.LogCapacity} {
+			if _, err := resource.ParseQuantity(capacity); err != nil {
+				return appsv1.StatefulSet{}, fmt.Errorf("yarn: nodemanager storage capacity: %w", err)
+			}
+		}
+		volumeClaimTemplates = append(com.AppendPVCs(NMLocalVolumeName, storage.StorageClass, storage.LocalCapacity),
+			com.AppendPVCs(NMLogVolumeName, storage.StorageClass, storage.LogCapacity)...)
+	}
+
 	podTemplate, err := BuildNMPodTemplate(hdfs, ssetSelector)
 	if err != nil {
 		return appsv1.StatefulSet{}, err
@@ -66,7 +90,8 @@ func BuildNMStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
 			Selector: &metav1.LabelSelector{
 				MatchLabels: ssetSelector,
 			},
-			Replicas:             &hdfs.Spec.Yarn.NMReplicas,
+			Replicas:             &replicas,
+			VolumeClaimTemplates: volumeClaimTemplates,
 			Template:             podTemplate,
 		},
 	}
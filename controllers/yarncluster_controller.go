@@ -0,0 +1,292 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	"github.com/dataworkbench/hdfs-operator/controllers/external"
+	"github.com/dataworkbench/hdfs-operator/controllers/yarn"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// YarnClusterReconciler reconciles a YarnCluster object
+type YarnClusterReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// YarnResources are the resources of a YARN cluster
+type YarnResources struct {
+	ConfigMap            corev1.ConfigMap
+	Secret               corev1.Secret
+	Services             []corev1.Service
+	PodDisruptionBudgets []policyv1.PodDisruptionBudget
+	StatefulSets         []appsv1.StatefulSet
+}
+
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=yarnclusters,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=yarnclusters/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=qy.dataworkbench.com,resources=yarnclusters/finalizers,verbs=update
+
+// Reconcile runs the YARN cluster of a YarnCluster against the referenced HDFS cluster. Its
+// resources are owned by the YarnCluster only, deleting the HDFS cluster leaves them running.
+func (r *YarnClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var yc v1.YarnCluster
+	if err := r.Client.Get(ctx, req.NamespacedName, &yc); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	status := *yc.Status.DeepCopy()
+	status.ObservedGeneration = yc.Generation
+	result, err := r.reconcileYarn(ctx, yc, &status)
+	if err != nil {
+		status.Phase, status.Message = v1.YarnClusterFailed, err.Error()
+	}
+
+	if !reflect.DeepEqual(status, yc.Status) {
+		yc.Status = status
+		if err := r.Client.Status().Update(ctx, &yc); err != nil {
+			if errors.IsConflict(err) {
+				return defaultRequeue, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	if err != nil {
+		return defaultRequeue, nil
+	}
+	return result, nil
+}
+
+func (r *YarnClusterReconciler) reconcileYarn(ctx context.Context, yc v1.YarnCluster, status *v1.YarnClusterStatus) (reconcile.Result, error) {
+	var hdfs v1.HDFS
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: yc.Namespace, Name: yc.Spec.HDFS}, &hdfs)
+	if errors.IsNotFound(err) {
+		status.Phase, status.Message = v1.YarnClusterPending, fmt.Sprintf("HDFS %s/%s not found", yc.Namespace, yc.Spec.HDFS)
+		return defaultRequeue, nil
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !reflect.DeepEqual(hdfs.Spec.Yarn, v1.Yarn{}) && hdfs.Spec.Yarn.Name == yc.Name {
+		return reconcile.Result{}, fmt.Errorf("HDFS %s already runs a YARN cluster named %s", hdfs.Name, yc.Name)
+	}
+	if yc.Spec.Version != "" && com.IsHadoop3(yc.Spec.Version) != com.IsHadoop3(hdfs.Spec.Version) {
+		return reconcile.Result{}, fmt.Errorf("YARN %s and HDFS %s are not of the same major version", yc.Spec.Version, hdfs.Spec.Version)
+	}
+
+	view := yarnClusterView(yc, hdfs)
+	ext, err := ResolveExternalConfig(ctx, r.Client, view)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	res, err := BuildYarnResources(view, ext)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if view.Spec.Yarn.Autoscaling != nil {
+		adopt := func(obj metav1.Object) { adoptYarnObject(yc, obj) }
+		if err := scaleNodeManagers(ctx, r.Client, view, adopt); err != nil {
+			return reconcile.Result{}, err
+		}
+		// the NodeManagers keep their autoscaled replicas when the StatefulSets are updated
+		if err := (&DefaultDriver{Client: r.Client, Hdfs: view}).keepNodeManagerReplicas(ctx, res.StatefulSets); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if err := r.apply(yc, res); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// follow the active ResourceManager
+	podNames := external.PodNames(yarn.RMStatefulSetName(view), view.Spec.Yarn.RMReplicas)
+	states := yarn.ForCluster(view).GetHAStates(ctx)
+	if err := (&DefaultDriver{Client: r.Client, Hdfs: view}).labelHAStates(ctx, podNames, states); err != nil {
+		return reconcile.Result{}, err
+	}
+	status.ActiveResourceManager = ""
+	for i, state := range states {
+		if state == com.HAStateActive && i < len(podNames) {
+			status.ActiveResourceManager = podNames[i]
+		}
+	}
+	var nm appsv1.StatefulSet
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: yc.Namespace, Name: yarn.NMStatefulSetName(view)}, &nm); err != nil {
+		return reconcile.Result{}, err
+	}
+	status.ReadyNodeManagers = nm.Status.ReadyReplicas
+	status.Phase, status.Message = v1.YarnClusterRunning, ""
+	return defaultRequeue, nil
+}
+
+// apply creates or updates the resources, owned by the YarnCluster
+func (r *YarnClusterReconciler) apply(yc v1.YarnCluster, res YarnResources) error {
+	adoptYarnObject(yc, &res.ConfigMap)
+	if _, err := ReconcileConfigMap(r.Client, res.ConfigMap, &yc); err != nil {
+		return fmt.Errorf("reconcile ConfigMap: %w", err)
+	}
+	adoptYarnObject(yc, &res.Secret)
+	if _, err := ReconcileSecret(r.Client, res.Secret, &yc); err != nil {
+		return fmt.Errorf("reconcile Secret: %w", err)
+	}
+	for i := range res.Services {
+		adoptYarnObject(yc, &res.Services[i])
+		if _, err := ReconcileService(r.Client, &res.Services[i], &yc); err != nil {
+			return fmt.Errorf("reconcile Service: %w", err)
+		}
+	}
+	for i := range res.PodDisruptionBudgets {
+		adoptYarnObject(yc, &res.PodDisruptionBudgets[i])
+		if _, err := ReconcilePodDisruptionBudget(r.Client, res.PodDisruptionBudgets[i], &yc); err != nil {
+			return fmt.Errorf("reconcile PodDisruptionBudget: %w", err)
+		}
+	}
+	for i := range res.StatefulSets {
+		sset := &res.StatefulSets[i]
+		adoptYarnObject(yc, sset)
+		// the HDFS network policies let the pods in
+		sset.Spec.Template.Labels = withYarnClusterLabel(sset.Spec.Template.Labels, yc.Name)
+		if _, err := ReconcileStatefulSet(r.Client, *sset, &yc); err != nil {
+			return fmt.Errorf("reconcile StatefulSet: %w", err)
+		}
+	}
+	return nil
+}
+
+// BuildYarnResources builds the resources of the YARN cluster of the HDFS spec
+func BuildYarnResources(hdfs v1.HDFS, ext com.ExternalConfig) (YarnResources, error) {
+	if hdfs.Spec.Yarn.RMReplicas < 1 {
+		return YarnResources{}, fmt.Errorf("yarn: at least one ResourceManager is needed")
+	}
+	if err := com.ValidateHeapPercents(v1.HDFSSpec{Yarn: hdfs.Spec.Yarn}); err != nil {
+		return YarnResources{}, err
+	}
+	config, err := yarn.BuildConfigMap(hdfs, ext)
+	if err != nil {
+		return YarnResources{}, err
+	}
+	secret, err := yarn.BuildSecret(hdfs, ext)
+	if err != nil {
+		return YarnResources{}, err
+	}
+	rm, err := yarn.BuildRMStatefulSet(hdfs)
+	if err != nil {
+		return YarnResources{}, err
+	}
+	nm, err := yarn.BuildNMStatefulSet(hdfs)
+	if err != nil {
+		return YarnResources{}, err
+	}
+	servers, err := yarn.BuildServerStatefulSets(hdfs)
+	if err != nil {
+		return YarnResources{}, err
+	}
+	services := []corev1.Service{
+		com.HeadlessService(hdfs, rm.Name, yarn.GetRMServicePorts()),
+		com.HeadlessService(hdfs, nm.Name, yarn.GetNMServicePorts()),
+		yarn.BuildActiveRMService(hdfs),
+	}
+	return YarnResources{
+		ConfigMap: config,
+		Secret:    secret,
+		Services:  append(services, yarn.BuildServerServices(hdfs)...),
+		PodDisruptionBudgets: []policyv1.PodDisruptionBudget{
+			com.PodDisruptionBudget(hdfs, rm.Name, com.DefaultMaxUnavailable),
+			com.PodDisruptionBudget(hdfs, nm.Name, com.DefaultMaxUnavailable),
+		},
+		StatefulSets: append([]appsv1.StatefulSet{rm, nm}, servers...),
+	}, nil
+}
+
+// yarnClusterView returns the HDFS cluster as seen by the YARN builders: the referenced cluster
+// running the YARN of the YarnCluster, with its image and version.
+func yarnClusterView(yc v1.YarnCluster, hdfs v1.HDFS) v1.HDFS {
+	view := *hdfs.DeepCopy()
+	view.Spec.Yarn = *yc.Spec.Yarn.DeepCopy()
+	view.Spec.Yarn.Name = yc.Name
+	if yc.Spec.Image != "" {
+		view.Spec.Image = yc.Spec.Image
+	}
+	if yc.Spec.ImagePullPolicy != "" {
+		view.Spec.ImagePullPolicy = yc.Spec.ImagePullPolicy
+	}
+	if yc.Spec.Version != "" {
+		view.Spec.Version = yc.Spec.Version
+	}
+	return view
+}
+
+// adoptYarnObject has the YarnCluster own the object instead of the HDFS cluster
+func adoptYarnObject(yc v1.YarnCluster, obj metav1.Object) {
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		*metav1.NewControllerRef(&yc, v1.GroupVersion.WithKind("YarnCluster")),
+	})
+	obj.SetLabels(withYarnClusterLabel(obj.GetLabels(), yc.Name))
+}
+
+// withYarnClusterLabel returns a copy of the labels with the YarnCluster one, label maps being
+// shared with selectors by the builders.
+func withYarnClusterLabel(labels map[string]string, name string) map[string]string {
+	copied := map[string]string{com.YarnClusterLabelName: name}
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
+
+// yarnClustersOf returns the requests of the YarnClusters running against the HDFS cluster
+func (r *YarnClusterReconciler) yarnClustersOf(obj client.Object) []reconcile.Request {
+	var list v1.YarnClusterList
+	if err := r.Client.List(context.Background(), &list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list YarnClusters of HDFS", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, yc := range list.Items {
+		if yc.Spec.HDFS == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: com.ExtractNamespacedName(&yc)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *YarnClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1.YarnCluster{}).
+		// render the filesystem config again when the HDFS cluster changes
+		Watches(&source.Kind{Type: &v1.HDFS{}}, handler.EnqueueRequestsFromMapFunc(r.yarnClustersOf)).
+		Complete(r)
+}
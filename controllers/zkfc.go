@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	nn "github.com/dataworkbench/hdfs-operator/controllers/namenode"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strings"
+)
+
+const FailoverReadyCondition = "FailoverReady"
+
+// updateZkfcStatus reports the health of the ZKFC container of each namenode pod, failover is
+// only ready when all of them are healthy.
+func (d *DefaultDriver) updateZkfcStatus(ctx context.Context) *Results {
+	results := &Results{}
+
+	var pods corev1.PodList
+	ssetName := com.GetName(d.Hdfs.Name, d.Hdfs.Spec.Namenode.Name)
+	if err := d.Client.List(ctx, &pods,
+		client.InNamespace(d.Hdfs.Namespace),
+		client.MatchingLabels(com.NewStatefulSetLabels(com.ExtractNamespacedName(&d.Hdfs), ssetName)),
+	); err != nil {
+		return results.WithError(err)
+	}
+
+	var statuses []v1.ZkfcStatus
+	var unhealthy []string
+	for _, pod := range pods.Items {
+		status := zkfcStatus(pod)
+		if !status.Healthy {
+			unhealthy = append(unhealthy, pod.Name)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Pod < statuses[j].Pod })
+	d.ReconcileState.UpdateZkfc(statuses)
+
+	switch {
+	case len(statuses) == 0:
+		d.ReconcileState.SetCondition(FailoverReadyCondition, metav1.ConditionFalse, "NoNamenode", "no namenode pod")
+	case len(unhealthy) > 0:
+		sort.Strings(unhealthy)
+		d.ReconcileState.SetCondition(FailoverReadyCondition, metav1.ConditionFalse, "ZkfcUnhealthy",
+			fmt.Sprintf("zkfc not running on %s", strings.Join(unhealthy, ", ")))
+		results.WithResult(defaultRequeue)
+	default:
+		d.ReconcileState.SetCondition(FailoverReadyCondition, metav1.ConditionTrue, "ZkfcRunning", "")
+	}
+	return results
+}
+
+func zkfcStatus(pod corev1.Pod) v1.ZkfcStatus {
+	status := v1.ZkfcStatus{Pod: pod.Name}
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Name != nn.ZkfcContainerName {
+			continue
+		}
+		status.Healthy = container.State.Running != nil
+		status.RestartCount = container.RestartCount
+		if last := container.LastTerminationState.Terminated; last != nil {
+			status.Message = fmt.Sprintf("exited with code %d: %s", last.ExitCode, last.Reason)
+		}
+	}
+	return status
+}
+
+// zkfcStatusChanged filters pod events down to the ones changing the ZKFC status of the pod.
+var zkfcStatusChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPod, ok := e.ObjectOld.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+		newPod, ok := e.ObjectNew.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+		return !reflect.DeepEqual(zkfcStatus(*oldPod), zkfcStatus(*newPod))
+	},
+}
+
+// hdfsOfNamenodePod returns the request of the HDFS cluster of a namenode pod, to report the
+// health of its ZKFC.
+func (r *HDFSReconciler) hdfsOfNamenodePod(obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels[com.TypeLabelName] != com.Type || labels[com.ClusterNameLabelName] == "" {
+		return nil
+	}
+	var hdfs v1.HDFS
+	nsn := types.NamespacedName{Namespace: obj.GetNamespace(), Name: labels[com.ClusterNameLabelName]}
+	if err := r.Client.Get(context.Background(), nsn, &hdfs); err != nil {
+		return nil
+	}
+	if labels[com.StatefulSetLabel] != com.GetName(hdfs.Name, hdfs.Spec.Namenode.Name) {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: nsn}}
+}
@@ -0,0 +1,105 @@
+package zookeeper
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strings"
+)
+
+const (
+	zkDataMountPath = "/data"
+	zkDataLogDir    = zkDataMountPath + "/datalog"
+	serverPort      = 2888
+	electionPort    = 3888
+)
+
+// BuildPodTemplateSpec builds a new PodTemplateSpec for the ZooKeeper members.
+func BuildPodTemplateSpec(hdfs v1.HDFS, labels map[string]string) corev1.PodTemplateSpec {
+	builder := &com.PodTemplateBuilder{}
+	builder.WithContainers(buildContainer(hdfs)).
+		WithImagePullSecrets(hdfs.Spec.ImagePullSecrets...).
+		WithRestartPolicy(corev1.RestartPolicyAlways).
+		WithPodSecurity(hdfs).
+		WithDefaultAntiAffinity(labels).
+		WithDefaultTopologySpread(labels).
+		WithTemplateMetadata(labels)
+
+	return builder.PodTemplate
+}
+
+func buildContainer(hdfs v1.HDFS) corev1.Container {
+	zk := hdfs.Spec.Zookeeper
+	image := zk.Image
+	if image == "" {
+		image = com.DefaultZookeeperImage
+	}
+	return corev1.Container{
+		ImagePullPolicy: corev1.PullPolicy(hdfs.Spec.ImagePullPolicy),
+		Image:           image,
+		Name:            "zookeeper",
+		Env:             envVars(hdfs),
+		// the id of a member is its ordinal plus one, ids start at 1
+		Command: []string{"bash", "-c", "mkdir -p " + zkDataLogDir +
+			" && export ZOO_MY_ID=$((${HOSTNAME##*-}+1)) && exec /docker-entrypoint.sh zkServer.sh start-foreground"},
+		Ports: getDefaultContainerPorts(),
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      ZKDataPvcName,
+			MountPath: zkDataMountPath,
+		}},
+		ReadinessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				Exec: &corev1.ExecAction{Command: []string{"zkServer.sh", "status"}},
+			},
+			InitialDelaySeconds: 10,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      5,
+		},
+		LivenessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(com.ZookeeperClientPort)},
+			},
+			InitialDelaySeconds: 30,
+			PeriodSeconds:       10,
+		},
+		Resources: zk.Resources,
+	}
+}
+
+func envVars(hdfs v1.HDFS) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "ZOO_SERVERS", Value: servers(hdfs)},
+		{Name: "ZOO_DATA_DIR", Value: zkDataMountPath},
+		{Name: "ZOO_DATA_LOG_DIR", Value: zkDataLogDir},
+		{Name: "ZOO_STANDALONE_ENABLED", Value: "false"},
+		{Name: "ZOO_4LW_COMMANDS_WHITELIST", Value: "srvr,ruok,mntr"},
+	}
+}
+
+// servers returns the members of the ensemble in the server.<id>=<host>:2888:3888;2181 form
+func servers(hdfs v1.HDFS) string {
+	members := make([]string, 0, hdfs.Spec.Zookeeper.Replicas)
+	for i := int32(0); i < hdfs.Spec.Zookeeper.Replicas; i++ {
+		members = append(members, fmt.Sprintf("server.%d=%s:%d:%d;%d",
+			i+1, com.ZookeeperHost(hdfs, i), serverPort, electionPort, com.ZookeeperClientPort))
+	}
+	return strings.Join(members, " ")
+}
+
+func GetDefaultServicePorts() []corev1.ServicePort {
+	return []corev1.ServicePort{
+		{Name: "client", Port: com.ZookeeperClientPort},
+		{Name: "server", Port: serverPort},
+		{Name: "election", Port: electionPort},
+	}
+}
+
+func getDefaultContainerPorts() []corev1.ContainerPort {
+	return []corev1.ContainerPort{
+		{Name: "client", ContainerPort: com.ZookeeperClientPort},
+		{Name: "server", ContainerPort: serverPort},
+		{Name: "election", ContainerPort: electionPort},
+	}
+}
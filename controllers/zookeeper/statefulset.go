@@ -0,0 +1,64 @@
+package zookeeper
+
+import (
+	"fmt"
+	v1 "github.com/dataworkbench/hdfs-operator/api/v1"
+	com "github.com/dataworkbench/hdfs-operator/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const ZKDataPvcName = "zkdata"
+
+// BuildStatefulSet builds the StatefulSet of the embedded ZooKeeper ensemble
+func BuildStatefulSet(hdfs v1.HDFS) (appsv1.StatefulSet, error) {
+	zk := hdfs.Spec.Zookeeper
+	if zk.Replicas != 3 && zk.Replicas != 5 {
+		return appsv1.StatefulSet{}, fmt.Errorf("zookeeper: %d replicas, an ensemble has 3 or 5 members", zk.Replicas)
+	}
+	// AppendPVCs expects a valid quantity
+	if _, err := resource.ParseQuantity(zk.Capacity); err != nil {
+		return appsv1.StatefulSet{}, fmt.Errorf("zookeeper: capacity: %w", err)
+	}
+	statefulSetName := com.ZookeeperName(hdfs)
+	// ssetSelector is used to match the StatefulSet pods
+	ssetSelector := com.NewStatefulSetLabels(com.ExtractNamespacedName(&hdfs), statefulSetName)
+
+	volumeClaimTemplates := com.AppendPVCs(ZKDataPvcName, zk.StorageClass, zk.Capacity)
+	podTemplate := BuildPodTemplateSpec(hdfs, ssetSelector)
+
+	sset := appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hdfs.Namespace,
+			Name:            statefulSetName,
+			Labels:          ssetSelector,
+			OwnerReferences: com.GetOwnerReference(hdfs),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.OnDeleteStatefulSetStrategyType,
+			},
+			// members only become ready once a quorum of them is up
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			ServiceName:         statefulSetName,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: ssetSelector,
+			},
+			Replicas:             &zk.Replicas,
+			VolumeClaimTemplates: volumeClaimTemplates,
+			Template:             podTemplate,
+		},
+	}
+	return sset, nil
+}
+
+// BuildService builds the headless Service the members of the ensemble find each other with
+func BuildService(hdfs v1.HDFS) corev1.Service {
+	return com.HeadlessService(hdfs, com.ZookeeperName(hdfs), GetDefaultServicePorts())
+}
@@ -85,6 +85,27 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "HDFS")
 		os.Exit(1)
 	}
+	if err = (&controllers.HDFSDirectoryReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HDFSDirectory")
+		os.Exit(1)
+	}
+	if err = (&controllers.HDFSSnapshotScheduleReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "HDFSSnapshotSchedule")
+		os.Exit(1)
+	}
+	if err = (&controllers.YarnClusterReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "YarnCluster")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {